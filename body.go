@@ -0,0 +1,233 @@
+package swagvalidator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Codes of the request body errors, set as Result.Code and rendered as the code of the error response
+const (
+	// CodeEmptyBody is the code of an empty body sent for a required body parameter
+	CodeEmptyBody = "empty_body"
+	// CodeSyntaxError is the code of a body that is not valid JSON
+	CodeSyntaxError = "syntax_error"
+	// CodeInvalidBodyType is the code of a JSON body of another type than the declared one e.g. an array for an object
+	CodeInvalidBodyType = "invalid_body_type"
+	// CodeTrailingData is the code of a JSON body followed by more data
+	CodeTrailingData = "trailing_data"
+	// CodeUnsupportedContentType is the code of a body of a media type that cannot be validated
+	CodeUnsupportedContentType = "unsupported_content_type"
+	// CodeUnreadableBody is the code of a body that failed to be read
+	CodeUnreadableBody = "unreadable_body"
+	// CodeBodyTooLarge is the code of a body larger than its maximum size, see MaxBodySize
+	CodeBodyTooLarge = "body_too_large"
+)
+
+// bodyError is the result of a request whose body failed with code
+func bodyError(code, description string) *Result {
+	return &Result{
+		Errors: map[string]string{"body": description},
+		Code:   code,
+	}
+}
+
+// formError is the result of a request whose form body failed to be parsed with err, nil without an error.
+// ParseForm parses the query string too, an error of the query alone is left to the query parameters.
+func formError(r *http.Request, err error, limited *limitedBody, limit int64) *Result {
+	if err == nil {
+		return nil
+	}
+	if limited.Exceeded() {
+		return bodyTooLarge(limit)
+	}
+	if _, queryErr := url.ParseQuery(r.URL.RawQuery); queryErr != nil && queryErr.Error() == err.Error() {
+		return nil
+	}
+	return bodyError(CodeUnreadableBody, "Failed to read request body")
+}
+
+// isJSON reports whether mediaType is parsed as json; a missing Content-Type is, for backwards compatibility
+func isJSON(mediaType string) bool {
+	return mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// decodeJSON decodes the json body b of rt, it returns the result of the request when b cannot be validated
+func (v *Validator) decodeJSON(rt *route, b []byte) (interface{}, *Result) {
+	var body interface{}
+	r := bytes.NewReader(b)
+	dec := json.NewDecoder(r)
+	// numbers are kept as json.Number, float64 would round integers above 2^53 before they are validated
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		offset, reason := len(b), "unexpected end of JSON input"
+		if err, ok := err.(*json.SyntaxError); ok {
+			// the offset of a syntax error follows the offending byte
+			offset, reason = int(err.Offset)-1, err.Error()
+		}
+		line, column := position(b, offset)
+		return nil, bodyError(CodeSyntaxError, fmt.Sprintf("Invalid JSON at line %d, column %d (offset %d): %s", line, column, offset, reason))
+	}
+
+	// what follows the value was either buffered by the decoder or not read yet
+	buffered, _ := ioutil.ReadAll(dec.Buffered())
+	end := len(b) - len(buffered) - r.Len()
+	if rest := bytes.TrimLeft(b[end:], " \t\r\n"); len(rest) > 0 {
+		offset := len(b) - len(rest)
+		line, column := position(b, offset)
+		return nil, bodyError(CodeTrailingData, fmt.Sprintf("Unexpected data after the JSON value at line %d, column %d (offset %d)", line, column, offset))
+	}
+
+	if rt.body != nil {
+		expected := schemaType(v.resolve(rt.body)["type"])
+		if given := jsonType(body); expected != "" && given != expected && !(expected == "number" && given == "integer") {
+			return nil, bodyError(CodeInvalidBodyType, v.describeType(expected, given))
+		}
+	}
+	return body, nil
+}
+
+// position returns the line and column, both starting at 1, of the byte at offset in b
+func position(b []byte, offset int) (int, int) {
+	if offset > len(b) {
+		offset = len(b)
+	}
+	line := 1 + bytes.Count(b[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(b[:offset], '\n')
+	return line, column
+}
+
+// jsonType returns the json schema type of a decoded json value
+func jsonType(value interface{}) string {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		if n, ok := new(big.Rat).SetString(string(value)); ok && n.IsInt() {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+// integerRanges holds the range of values of the Go integer kinds. The swagger format of a property
+// is not enough, as int and uint32 fields are declared int32 and uint64 fields int64.
+var integerRanges = map[reflect.Kind][2]*big.Int{
+	reflect.Int:    signedRange(strconv.IntSize),
+	reflect.Int8:   signedRange(8),
+	reflect.Int16:  signedRange(16),
+	reflect.Int32:  signedRange(32),
+	reflect.Int64:  signedRange(64),
+	reflect.Uint:   unsignedRange(strconv.IntSize),
+	reflect.Uint8:  unsignedRange(8),
+	reflect.Uint16: unsignedRange(16),
+	reflect.Uint32: unsignedRange(32),
+	reflect.Uint64: unsignedRange(64),
+}
+
+// signedRange returns the minimum and maximum of a signed integer of bits
+func signedRange(bits uint) [2]*big.Int {
+	max := new(big.Int).Lsh(big.NewInt(1), bits-1)
+	min := new(big.Int).Neg(max)
+	return [2]*big.Int{min, max.Sub(max, big.NewInt(1))}
+}
+
+// unsignedRange returns the minimum and maximum of an unsigned integer of bits
+func unsignedRange(bits uint) [2]*big.Int {
+	max := new(big.Int).Lsh(big.NewInt(1), bits)
+	return [2]*big.Int{big.NewInt(0), max.Sub(max, big.NewInt(1))}
+}
+
+// checkIntegers adds to errors the integers of the decoded body value that do not fit the Go kind of the
+// definition property they are sent for, keyed by their field as the schema errors are e.g. items.0.id
+func (v *Validator) checkIntegers(schema map[string]interface{}, value interface{}, field string, errors map[string]string) {
+	name := ""
+	if ref, _ := schema["$ref"].(string); strings.Contains(ref, "#/definitions/") {
+		name = ref[strings.Index(ref, "#/definitions/")+len("#/definitions/"):]
+	}
+	schema = v.resolve(schema)
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		for k, val := range value {
+			key := joinField(field, k)
+			if kind, found := v.integerKinds[name][k]; found {
+				v.checkInteger(kind, val, key, errors)
+				continue
+			}
+			if prop, found := properties[k].(map[string]interface{}); found {
+				v.checkIntegers(prop, val, key, errors)
+			}
+		}
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		for i, val := range value {
+			v.checkIntegers(items, val, joinField(field, strconv.Itoa(i)), errors)
+		}
+	}
+}
+
+// joinField appends the property or index k to the field of the body it belongs to, empty for the body itself
+func joinField(field, k string) string {
+	if field == "" {
+		return k
+	}
+	return field + "." + k
+}
+
+// checkInteger adds to errors the integer value, or the integers of the array value, out of the range of kind
+func (v *Validator) checkInteger(kind reflect.Kind, value interface{}, field string, errors map[string]string) {
+	if values, isArray := value.([]interface{}); isArray {
+		for i, val := range values {
+			v.checkInteger(kind, val, joinField(field, strconv.Itoa(i)), errors)
+		}
+		return
+	}
+
+	number, isNumber := value.(json.Number)
+	n, ok := new(big.Rat).SetString(string(number))
+	if !isNumber || !ok || !n.IsInt() {
+		// anything but an integer fails the type of the schema
+		return
+	}
+	bounds := integerRanges[kind]
+	if n.Num().Cmp(bounds[0]) < 0 {
+		errors[field] = v.describeBound("number_gte", "min", bounds[0])
+	} else if n.Num().Cmp(bounds[1]) > 0 {
+		errors[field] = v.describeBound("number_lte", "max", bounds[1])
+	}
+}
+
+// describeBound describes a number out of bound, with the template of the gojsonschema error type
+func (v *Validator) describeBound(errType, detail string, bound *big.Int) string {
+	description := describeDetails(v.templates, errType, map[string]interface{}{detail: bound.String()})
+	if description != "" {
+		return description
+	}
+	if detail == "min" {
+		return "Must be greater than or equal to " + bound.String()
+	}
+	return "Must be less than or equal to " + bound.String()
+}
+
+// unsupportedContentType is the result of a request with a body of mediaType, which cannot be validated
+func unsupportedContentType(mediaType string) *Result {
+	result := bodyError(CodeUnsupportedContentType, fmt.Sprintf("Unsupported content type %s", mediaType))
+	result.Status = http.StatusUnsupportedMediaType
+	return result
+}
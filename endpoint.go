@@ -0,0 +1,54 @@
+package swagvalidator
+
+import (
+	"reflect"
+
+	"github.com/miketonks/swag/endpoint"
+	"github.com/miketonks/swag/swagger"
+)
+
+// Cookie defines a cookie parameter for the endpoint; name, typ, format, description, and required correspond to the
+// matching swagger fields. Cookie parameters are an OpenAPI 3 feature, swagger 2.0 tooling may ignore them.
+func Cookie(name, typ, format, description string, required bool) endpoint.Option {
+	return CookieMap(map[string]swagger.Parameter{
+		name: {
+			Type:        typ,
+			Format:      format,
+			Description: description,
+			Required:    required,
+		},
+	})
+}
+
+// CookieMap allows us to define multiple cookie parameters in a map / struct format
+func CookieMap(params map[string]swagger.Parameter) endpoint.Option {
+	return func(b *endpoint.Builder) {
+		if b.Endpoint.Parameters == nil {
+			b.Endpoint.Parameters = []swagger.Parameter{}
+		}
+
+		for k, v := range params {
+			v.Name = k
+			v.In = "cookie"
+			b.Endpoint.Parameters = append(b.Endpoint.Parameters, v)
+		}
+	}
+}
+
+// DeepObject defines an object query parameter sent as bracketed or dotted keys e.g. filter[status]=active
+// or filter.status=active; prototype is reflected upon like the prototype of endpoint.Body
+func DeepObject(name string, prototype interface{}, description string, required bool) endpoint.Option {
+	return func(b *endpoint.Builder) {
+		if b.Endpoint.Parameters == nil {
+			b.Endpoint.Parameters = []swagger.Parameter{}
+		}
+
+		b.Endpoint.Parameters = append(b.Endpoint.Parameters, swagger.Parameter{
+			In:          "query",
+			Name:        name,
+			Description: description,
+			Required:    required,
+			Schema:      swagger.MakeSchema(reflect.TypeOf(prototype)),
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package swagvalidator
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sniffLen is the number of bytes http.DetectContentType considers
+const sniffLen = 512
+
+// FileConstraints restricts the files uploaded for a file parameter, zero values are not checked
+type FileConstraints struct {
+	// MinSize and MaxSize bound the size of every file in bytes
+	MinSize int64
+	MaxSize int64
+	// MIMETypes lists the allowed media types e.g. image/png or image/*, checked against the Content-Type
+	// declared by the client and the one sniffed from the file content, unless the sniffed type is generic
+	// e.g. text/plain for a text/csv file
+	MIMETypes []string
+	// Extensions lists the allowed file name extensions e.g. .png, compared case-insensitively
+	Extensions []string
+	// MaxCount is the maximum number of files uploaded for the parameter
+	MaxCount int
+}
+
+// uploadedFile describes a file received for a file parameter
+type uploadedFile struct {
+	filename string
+	// contentType is the Content-Type declared by the client
+	contentType string
+	size        int64
+	// head holds the first bytes of the content, enough to sniff its media type
+	head []byte
+}
+
+// check returns the errors of the files uploaded for the parameter name, keyed by the parameter name
+// and the index of the file e.g. avatar.0
+func (c FileConstraints) check(name string, files []*multipart.FileHeader) map[string]string {
+	errors := map[string]string{}
+	if description := c.checkCount(len(files)); description != "" {
+		errors[name] = description
+	}
+
+	for i, fh := range files {
+		key := name + "." + strconv.Itoa(i)
+		f, err := readHead(fh)
+		if err != nil {
+			errors[key] = fmt.Sprintf("File %s could not be read", fh.Filename)
+			continue
+		}
+		if description := c.checkSize(f); description != "" {
+			errors[key] = description
+		} else if description := c.checkType(f); description != "" {
+			errors[key] = description
+		}
+	}
+	return errors
+}
+
+// checkCount describes the violation of MaxCount by count files, if any
+func (c FileConstraints) checkCount(count int) string {
+	if c.MaxCount > 0 && count > c.MaxCount {
+		return fmt.Sprintf("At most %d files are allowed", c.MaxCount)
+	}
+	return ""
+}
+
+// checkSize describes the violation of MinSize or MaxSize by f, if any
+func (c FileConstraints) checkSize(f uploadedFile) string {
+	if c.MinSize > 0 && f.size < c.MinSize {
+		return fmt.Sprintf("File %s must be at least %d bytes, given: %d", f.filename, c.MinSize, f.size)
+	}
+	if c.MaxSize > 0 && f.size > c.MaxSize {
+		return fmt.Sprintf("File %s must be at most %d bytes", f.filename, c.MaxSize)
+	}
+	return ""
+}
+
+// checkType describes the violation of Extensions or MIMETypes by f, if any
+func (c FileConstraints) checkType(f uploadedFile) string {
+	if len(c.Extensions) > 0 && !containsFold(c.Extensions, filepath.Ext(f.filename)) {
+		return fmt.Sprintf("File %s must have one of the following extensions: %s", f.filename, strings.Join(c.Extensions, ", "))
+	}
+
+	if len(c.MIMETypes) > 0 {
+		// application/octet-stream is what clients declare when they do not know the type
+		declared, _, _ := mime.ParseMediaType(f.contentType)
+		if declared == "application/octet-stream" {
+			declared = ""
+		}
+		if declared != "" && !matchMediaType(c.MIMETypes, declared) {
+			return fmt.Sprintf("File %s must be one of the following types: %s, given: %s", f.filename, strings.Join(c.MIMETypes, ", "), declared)
+		}
+
+		// the sniffer only tells a few dozen types apart, a generic result does not contradict the declared type
+		sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(f.head))
+		if declared != "" && genericTypes[sniffed] {
+			return ""
+		}
+		if !matchMediaType(c.MIMETypes, sniffed) {
+			return fmt.Sprintf("File %s must be one of the following types: %s, given: %s", f.filename, strings.Join(c.MIMETypes, ", "), sniffed)
+		}
+	}
+	return ""
+}
+
+// genericTypes are sniffed from the content of many media types e.g. text/csv and application/json
+// are sniffed as text/plain, image/svg+xml as text/xml and .docx files as application/zip
+var genericTypes = map[string]bool{
+	"text/plain":               true,
+	"text/xml":                 true,
+	"application/octet-stream": true,
+	"application/zip":          true,
+}
+
+// readHead describes the parsed file fh, reading the head of its content
+func readHead(fh *multipart.FileHeader) (uploadedFile, error) {
+	f := uploadedFile{
+		filename:    fh.Filename,
+		contentType: fh.Header.Get("Content-Type"),
+		size:        fh.Size,
+	}
+
+	file, err := fh.Open()
+	if err != nil {
+		return f, err
+	}
+	defer file.Close()
+
+	f.head = make([]byte, sniffLen)
+	n, err := io.ReadFull(file, f.head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return f, err
+	}
+	f.head = f.head[:n]
+	return f, nil
+}
+
+// matchMediaType reports whether mediaType is one of allowed, which may hold wildcards e.g. image/*
+func matchMediaType(allowed []string, mediaType string) bool {
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if a == mediaType || a == "*/*" || (strings.HasSuffix(a, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(a, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether s is one of values, ignoring case
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
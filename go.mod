@@ -3,7 +3,9 @@ module github.com/Rekfuki/swag-validator
 go 1.13
 
 require (
-	github.com/gin-gonic/gin v1.4.0
+	github.com/gin-gonic/gin v1.6.3
+	github.com/go-chi/chi v4.0.2+incompatible
+	github.com/gorilla/mux v1.7.4
 	github.com/labstack/echo v3.3.10+incompatible
 	github.com/labstack/echo/v4 v4.1.11
 	github.com/miketonks/swag v0.0.0-20191028095334-d5fe47229537
@@ -1,8 +1,17 @@
 package swagvalidator
 
+import (
+	"bytes"
+	"math/big"
+	"text/template"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
 type (
-	// locale is an interface for defining custom error strings
-	locale interface {
+	// ErrorLocale defines the error strings of a Validator, see Locale; embed CustomLocale
+	// to only override some of them
+	ErrorLocale interface {
 		False() string
 		Required() string
 		InvalidType() string
@@ -62,7 +71,7 @@ type (
 		ErrorFormat() string
 	}
 
-	// CustomLocale is a locale for schema validator
+	// CustomLocale is the default ErrorLocale
 	CustomLocale struct{}
 )
 
@@ -325,3 +334,95 @@ func (l CustomLocale) ConditionThen() string {
 func (l CustomLocale) ConditionElse() string {
 	return `Must validate "else" as "i"`
 }
+
+// errorTemplates compiles the validation error format-strings of l keyed by gojsonschema error type
+func errorTemplates(l ErrorLocale) map[string]*template.Template {
+	formats := map[string]string{
+		"false":                           l.False(),
+		"required":                        l.Required(),
+		"invalid_type":                    l.InvalidType(),
+		"number_any_of":                   l.NumberAnyOf(),
+		"number_one_of":                   l.NumberOneOf(),
+		"number_all_of":                   l.NumberAllOf(),
+		"number_not":                      l.NumberNot(),
+		"missing_dependency":              l.MissingDependency(),
+		"internal":                        l.Internal(),
+		"const":                           l.Const(),
+		"enum":                            l.Enum(),
+		"array_no_additional_items":       l.ArrayNoAdditionalItems(),
+		"array_min_items":                 l.ArrayMinItems(),
+		"array_max_items":                 l.ArrayMaxItems(),
+		"unique":                          l.Unique(),
+		"contains":                        l.ArrayContains(),
+		"array_min_properties":            l.ArrayMinProperties(),
+		"array_max_properties":            l.ArrayMaxProperties(),
+		"additional_property_not_allowed": l.AdditionalPropertyNotAllowed(),
+		"invalid_property_pattern":        l.InvalidPropertyPattern(),
+		"invalid_property_name":           l.InvalidPropertyName(),
+		"string_gte":                      l.StringGTE(),
+		"string_lte":                      l.StringLTE(),
+		"pattern":                         l.DoesNotMatchPattern(),
+		"format":                          l.DoesNotMatchFormat(),
+		"multiple_of":                     l.MultipleOf(),
+		"number_gte":                      l.NumberGTE(),
+		"number_gt":                       l.NumberGT(),
+		"number_lte":                      l.NumberLTE(),
+		"number_lt":                       l.NumberLT(),
+		"condition_then":                  l.ConditionThen(),
+		"condition_else":                  l.ConditionElse(),
+	}
+
+	templates := map[string]*template.Template{}
+	for t, f := range formats {
+		tpl := template.New(t)
+		if gojsonschema.ErrorTemplateFuncs != nil {
+			tpl.Funcs(gojsonschema.ErrorTemplateFuncs)
+		}
+		if tpl, err := tpl.Parse(f); err == nil {
+			templates[t] = tpl
+		}
+	}
+	return templates
+}
+
+// describe formats err with the template for its type, falling back to the description gojsonschema produced
+func describe(templates map[string]*template.Template, err gojsonschema.ResultError) string {
+	tpl, found := templates[err.Type()]
+	if !found {
+		return err.Description()
+	}
+
+	var description bytes.Buffer
+	if e := tpl.Execute(&description, exactDetails(err.Details())); e != nil {
+		return err.Description()
+	}
+	return description.String()
+}
+
+// exactDetails returns details with the integral bounds written in full, a *big.Float prints
+// 9007199254740993 as 9.007199254740993e+15
+func exactDetails(details gojsonschema.ErrorDetails) gojsonschema.ErrorDetails {
+	exact := gojsonschema.ErrorDetails{}
+	for k, detail := range details {
+		if f, ok := detail.(*big.Float); ok && f.IsInt() {
+			detail = f.Text('f', 0)
+		}
+		exact[k] = detail
+	}
+	return exact
+}
+
+// describeDetails formats the template of the gojsonschema error type with details as describe does,
+// for the errors found outside of schema validation; it returns an empty string when that fails
+func describeDetails(templates map[string]*template.Template, errType string, details map[string]interface{}) string {
+	tpl, found := templates[errType]
+	if !found {
+		return ""
+	}
+
+	var description bytes.Buffer
+	if err := tpl.Execute(&description, details); err != nil {
+		return ""
+	}
+	return description.String()
+}
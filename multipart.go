@@ -0,0 +1,188 @@
+package swagvalidator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+// readMultipart reads the multipart body of r part by part into formData, checking the names, the types
+// and the file constraints of the parts as they arrive. It stops at the first violation and returns its
+// result; otherwise the body of r is replaced by the parts that were kept, re-encoded with the same boundary.
+func (v *Validator) readMultipart(rt *route, r *http.Request, formData map[string]interface{}) *Result {
+	// r.MultipartReader is not used, as it keeps the next handler from parsing the rebuilt body
+	_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if params["boundary"] == "" || r.Body == nil {
+		return bodyError(CodeUnreadableBody, "Failed to read request body")
+	}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	if err := mw.SetBoundary(params["boundary"]); err != nil {
+		return bodyError(CodeUnreadableBody, "Failed to read request body")
+	}
+
+	declared := rt.params["formData"]
+	values := map[string][]string{}
+	counts := map[string]int{}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return bodyError(CodeUnreadableBody, "Failed to read request body")
+		}
+
+		name := part.FormName()
+		_, isDeclared := declared[name]
+		if !isDeclared {
+			switch v.unknown["formData"] {
+			case RejectUnknown:
+				return &Result{Errors: map[string]string{name: "Unknown form parameter"}}
+			case StripUnknown:
+				continue
+			}
+		}
+
+		pw, err := mw.CreatePart(part.Header)
+		if err != nil {
+			return bodyError(CodeUnreadableBody, "Failed to read request body")
+		}
+
+		isFile := isFileParam(rt, name)
+		if part.FileName() == "" {
+			if isDeclared && isFile {
+				return &Result{Errors: map[string]string{name: v.describeType("file", "string")}}
+			}
+
+			value, err := ioutil.ReadAll(io.LimitReader(part, v.multipartMemory+1))
+			if err != nil {
+				return bodyError(CodeUnreadableBody, "Failed to read request body")
+			}
+			if int64(len(value)) > v.multipartMemory {
+				return &Result{Errors: map[string]string{name: fmt.Sprintf("Value must be at most %d bytes", v.multipartMemory)}}
+			}
+			if description := v.checkValue(rt, name, string(value)); description != "" {
+				return &Result{Errors: map[string]string{name: description}}
+			}
+			pw.Write(value)
+
+			if isDeclared {
+				values[name] = append(values[name], string(value))
+			}
+			continue
+		}
+
+		if !isDeclared {
+			if _, err := io.Copy(pw, part); err != nil {
+				return bodyError(CodeUnreadableBody, "Failed to read request body")
+			}
+			continue
+		}
+		if !isFile {
+			prop, _ := declared[name].(map[string]interface{})
+			return &Result{Errors: map[string]string{name: v.describeType(schemaType(prop["type"]), "file")}}
+		}
+
+		c := rt.files[name]
+		counts[name]++
+		if description := c.checkCount(counts[name]); description != "" {
+			return &Result{Errors: map[string]string{name: description}}
+		}
+		key := name + "." + strconv.Itoa(counts[name]-1)
+
+		// the type is checked on the head of the file, before the rest of it is read
+		f := uploadedFile{
+			filename:    part.FileName(),
+			contentType: part.Header.Get("Content-Type"),
+			head:        make([]byte, sniffLen),
+		}
+		n, err := io.ReadFull(part, f.head)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return bodyError(CodeUnreadableBody, "Failed to read request body")
+		}
+		f.head = f.head[:n]
+		if description := c.checkType(f); description != "" {
+			return &Result{Errors: map[string]string{key: description}}
+		}
+		pw.Write(f.head)
+
+		// a file over MaxSize is rejected as soon as its first extra byte arrives
+		var rest io.Reader = part
+		if c.MaxSize > 0 {
+			rest = io.LimitReader(part, c.MaxSize-int64(n)+1)
+		}
+		copied, err := io.Copy(pw, rest)
+		if err != nil {
+			return bodyError(CodeUnreadableBody, "Failed to read request body")
+		}
+		f.size = int64(n) + copied
+		if description := c.checkSize(f); description != "" {
+			return &Result{Errors: map[string]string{key: description}}
+		}
+
+		formData[name] = "x"
+	}
+	mw.Close()
+
+	for k, f := range values {
+		formData[k] = rt.formValue(k, f)
+	}
+
+	r.Body = ioutil.NopCloser(buf)
+	r.ContentLength = int64(buf.Len())
+	return nil
+}
+
+// checkValue describes the invalid type of the value of the scalar form field name, if any.
+// The value is validated against the full schema of the field later on, along with the other fields.
+func (v *Validator) checkValue(rt *route, name, value string) string {
+	prop, _ := rt.params["formData"][name].(map[string]interface{})
+	switch t := schemaType(prop["type"]); t {
+	case "integer", "number", "boolean":
+		if _, isString := loadValueForKey(rt.params["formData"], name, []string{value}, "").(string); isString {
+			return v.describeType(t, "string")
+		}
+	}
+	return ""
+}
+
+// describeType describes a value of type given where type expected is declared
+func (v *Validator) describeType(expected, given string) string {
+	description := describeDetails(v.templates, "invalid_type", map[string]interface{}{
+		"expected": expected,
+		"given":    given,
+	})
+	if description == "" {
+		return fmt.Sprintf("Invalid type. Expected: %s, given: %s", expected, given)
+	}
+	return description
+}
+
+// describeRequired describes the missing required property
+func (v *Validator) describeRequired(property string) string {
+	description := describeDetails(v.templates, "required", map[string]interface{}{
+		"property": property,
+	})
+	if description == "" {
+		return property + " is required"
+	}
+	return description
+}
+
+// isFileParam reports whether the form parameter name of rt is a file
+func isFileParam(rt *route, name string) bool {
+	for _, p := range rt.endpoint.Parameters {
+		if p.In == "formData" && p.Name == name {
+			return p.Type == "file"
+		}
+	}
+	return false
+}
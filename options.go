@@ -0,0 +1,165 @@
+package swagvalidator
+
+import (
+	"net/http"
+	"strings"
+
+	swag "github.com/miketonks/swag"
+)
+
+// Option customises a Validator
+type Option func(v *Validator)
+
+// ErrorRenderer builds the response status and body for a request that failed validation.
+// err is set when the endpoint schema could not be compiled, result holds the validation errors otherwise.
+type ErrorRenderer func(r *http.Request, result *Result, err error) (int, interface{})
+
+// Hook is called with the outcome of every validated request
+type Hook func(r *http.Request, result *Result, err error)
+
+// UnknownPolicy decides what happens to the parameters of a request the endpoint does not declare
+type UnknownPolicy int
+
+const (
+	// IgnoreUnknown passes undeclared parameters on without validating them
+	IgnoreUnknown UnknownPolicy = iota
+	// RejectUnknown fails the validation of requests with undeclared parameters
+	RejectUnknown
+	// StripUnknown removes undeclared parameters from the request before it is passed on
+	StripUnknown
+)
+
+// MatchHandlerName makes the Gin middleware fall back to matching endpoints by the name
+// of their handler function when the route cannot be matched by its full path
+func MatchHandlerName() Option {
+	return func(v *Validator) {
+		v.matchHandlerName = true
+	}
+}
+
+// RenderErrors replaces the default "Validation error" response
+func RenderErrors(render ErrorRenderer) Option {
+	return func(v *Validator) {
+		v.render = render
+	}
+}
+
+// Skip passes the requests for which skip returns true through without validating them
+func Skip(skip func(r *http.Request) bool) Option {
+	return func(v *Validator) {
+		v.skip = skip
+	}
+}
+
+// MultipartMemory sets the number of bytes of a multipart body kept in memory, the rest
+// of the parts is stored in temporary files; defaults to MaxMemory. With StreamMultipart
+// it is also the maximum size of every text field.
+func MultipartMemory(n int64) Option {
+	return func(v *Validator) {
+		v.multipartMemory = n
+	}
+}
+
+// StreamMultipart makes the Validator walk multipart bodies part by part rather than parsing them with
+// ParseMultipartForm, rejecting undeclared fields, mistyped values and files that violate their constraints
+// as soon as they arrive. The parts are kept in memory and passed on to the next handler as the request body,
+// so only the endpoints with a body size limit are streamed, see MaxBodySize and EndpointMaxBodySize.
+func StreamMultipart() Option {
+	return func(v *Validator) {
+		v.streaming = true
+	}
+}
+
+// MaxBodySize sets the maximum size in bytes of the request bodies of every endpoint, larger bodies fail with
+// 413 Request Entity Too Large as soon as the limit is read; zero or less disables it. Defaults to DefaultMaxBodySize.
+func MaxBodySize(n int64) Option {
+	return func(v *Validator) {
+		v.maxBodySize = n
+	}
+}
+
+// EndpointMaxBodySize overrides MaxBodySize for the endpoint identified by its method and path
+// as declared e.g. "POST", "/pets/{id}/photos"
+func EndpointMaxBodySize(method, path string, n int64) Option {
+	return func(v *Validator) {
+		v.bodySizes[strings.ToUpper(method)+" "+swag.ColonPath(path)] = n
+	}
+}
+
+// Locale sets the locale used to describe validation errors; defaults to CustomLocale
+func Locale(l ErrorLocale) Option {
+	return func(v *Validator) {
+		v.locale = l
+	}
+}
+
+// UnknownParams sets the policy for undeclared parameters in the given locations, any of query,
+// header and formData; query and formData when none are given. Undeclared parameters are ignored by default.
+// Standard headers and the headers listed by KnownHeaders are passed on whatever the header policy.
+func UnknownParams(policy UnknownPolicy, in ...string) Option {
+	if len(in) == 0 {
+		in = []string{"query", "formData"}
+	}
+	return func(v *Validator) {
+		for _, l := range in {
+			v.unknown[l] = policy
+		}
+	}
+}
+
+// KnownHeaders adds names to the standard headers e.g. User-Agent or Authorization, which are never
+// unknown to the header policy of UnknownParams, for the headers set by proxies or middlewares e.g. X-Trace-Id
+func KnownHeaders(names ...string) Option {
+	return func(v *Validator) {
+		for _, name := range names {
+			v.knownHeaders[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// CollectionFormat declares the collectionFormat of an array parameter, one of csv, ssv, tsv, pipes
+// and multi, which the swag endpoint builder cannot express. The endpoint is identified by its method
+// and path as declared e.g. "GET", "/pets/{id}", and the parameter by its location and name e.g. "query", "tags".
+func CollectionFormat(method, path, in, name, format string) Option {
+	return func(v *Validator) {
+		key := strings.ToUpper(method) + " " + swag.ColonPath(path)
+		if v.formats[key] == nil {
+			v.formats[key] = map[string]string{}
+		}
+		v.formats[key][in+"."+name] = format
+	}
+}
+
+// Files restricts the files uploaded for the file parameter name of the endpoint identified by
+// its method and path as declared e.g. "POST", "/pets/{id}/photos"
+func Files(method, path, name string, c FileConstraints) Option {
+	return func(v *Validator) {
+		key := strings.ToUpper(method) + " " + swag.ColonPath(path)
+		if v.files[key] == nil {
+			v.files[key] = map[string]FileConstraints{}
+		}
+		v.files[key][name] = c
+	}
+}
+
+// AfterValidate calls hook with the outcome of every validated request
+func AfterValidate(hook Hook) Option {
+	return func(v *Validator) {
+		v.hooks = append(v.hooks, hook)
+	}
+}
+
+// renderErrors is the default ErrorRenderer
+func renderErrors(r *http.Request, result *Result, err error) (int, interface{}) {
+	if err != nil {
+		return http.StatusInternalServerError, schemaError(err)
+	}
+	body := validationError(result.Errors)
+	if result.Code != "" {
+		body["code"] = result.Code
+	}
+	if result.Status != 0 {
+		return result.Status, body
+	}
+	return http.StatusBadRequest, body
+}
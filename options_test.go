@@ -0,0 +1,857 @@
+package swagvalidator_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	swag "github.com/miketonks/swag"
+	"github.com/miketonks/swag/endpoint"
+	"github.com/miketonks/swag/swagger"
+	"github.com/stretchr/testify/assert"
+
+	sv "github.com/Rekfuki/swag-validator"
+)
+
+type shortLocale struct {
+	sv.CustomLocale
+}
+
+var _ sv.ErrorLocale = shortLocale{}
+
+func (shortLocale) InvalidType() string {
+	return `want {{.expected}}`
+}
+
+func TestOptions(t *testing.T) {
+	var hooked []string
+
+	testTable := []struct {
+		description      string
+		opts             []sv.Option
+		url              string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			description:      "Default error response",
+			url:              "/pets?limit=abc",
+			expectedStatus:   400,
+			expectedResponse: `{"details":{"limit":"Invalid type. Expected: integer, given: string"},"message":"Validation error"}`,
+		},
+		{
+			description: "Custom error response",
+			opts: []sv.Option{sv.RenderErrors(func(r *http.Request, result *sv.Result, err error) (int, interface{}) {
+				return http.StatusUnprocessableEntity, map[string]interface{}{"errors": result.Errors}
+			})},
+			url:              "/pets?limit=abc",
+			expectedStatus:   422,
+			expectedResponse: `{"errors":{"limit":"Invalid type. Expected: integer, given: string"}}`,
+		},
+		{
+			description: "Skipped request",
+			opts: []sv.Option{sv.Skip(func(r *http.Request) bool {
+				return r.Header.Get("X-Skip-Validation") == "true"
+			})},
+			url:            "/pets?limit=abc&skip=true",
+			expectedStatus: 200,
+		},
+		{
+			description:      "Custom locale",
+			opts:             []sv.Option{sv.Locale(shortLocale{})},
+			url:              "/pets?limit=abc",
+			expectedStatus:   400,
+			expectedResponse: `{"details":{"limit":"want integer"},"message":"Validation error"}`,
+		},
+		{
+			description:    "Undeclared parameter ignored by default",
+			url:            "/pets?limit=10&foo=bar",
+			expectedStatus: 200,
+		},
+		{
+			description:      "Undeclared parameters rejected",
+			opts:             []sv.Option{sv.UnknownParams(sv.RejectUnknown)},
+			url:              "/pets?limit=10&foo=bar&_=123",
+			expectedStatus:   400,
+			expectedResponse: `{"details":{"foo":"Unknown query parameter","_":"Unknown query parameter"},"message":"Validation error"}`,
+		},
+		{
+			description:    "Undeclared parameter ignored",
+			opts:           []sv.Option{sv.UnknownParams(sv.RejectUnknown), sv.UnknownParams(sv.IgnoreUnknown, "query")},
+			url:            "/pets?limit=10&foo=bar",
+			expectedStatus: 200,
+		},
+		{
+			description: "Hook",
+			opts: []sv.Option{sv.AfterValidate(func(r *http.Request, result *sv.Result, err error) {
+				hooked = append(hooked, r.URL.RawQuery)
+			})},
+			url:            "/pets?limit=10",
+			expectedStatus: 200,
+		},
+	}
+
+	api := swag.New(swag.Endpoints(endpoint.New("GET", "/pets", "List pets",
+		endpoint.Query("limit", "integer", "", "", false),
+	)))
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+			h := sv.SwaggerValidatorHTTP(api, tt.opts...)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest("GET", tt.url, nil)
+			if err != nil {
+				log.Fatalf("Error preparing request: %s", err)
+			}
+			if strings.Contains(tt.url, "skip=true") {
+				req.Header.Set("X-Skip-Validation", "true")
+			}
+
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedResponse != "" {
+				assert.JSONEq(t, tt.expectedResponse, w.Body.String())
+			}
+		})
+	}
+
+	assert.Equal(t, []string{"limit=10"}, hooked)
+}
+
+func TestStripUnknown(t *testing.T) {
+	api := swag.New(swag.Endpoints(endpoint.New("GET", "/pets", "List pets",
+		endpoint.Query("limit", "integer", "", "", false),
+		endpoint.RequestHeader("X-Request-ID", "string", "", "", false),
+	)))
+
+	var query string
+	var header http.Header
+	h := sv.SwaggerValidatorHTTP(api, sv.UnknownParams(sv.StripUnknown, "query", "header"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		header = r.Header
+	}))
+
+	req, err := http.NewRequest("GET", "/pets?limit=10&utm_source=mail", nil)
+	if err != nil {
+		log.Fatalf("Error preparing request: %s", err)
+	}
+	req.Header.Set("X-Request-ID", "abc")
+	req.Header.Set("X-Other", "abc")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "limit=10", query)
+	assert.Equal(t, http.Header{"X-Request-Id": {"abc"}}, header)
+}
+
+func TestUnknownHeaders(t *testing.T) {
+	api := swag.New(swag.Endpoints(endpoint.New("POST", "/pets", "Add a pet",
+		endpoint.RequestHeader("X-Request-ID", "string", "", "", false),
+		endpoint.Body(payload{}, "Pet", false),
+	)))
+
+	prepareRequest := func(extra map[string]string) *http.Request {
+		req, err := http.NewRequest("POST", "/pets", strings.NewReader(`{}`))
+		if err != nil {
+			log.Fatalf("Error preparing request: %s", err)
+		}
+		for k, h := range map[string]string{
+			"Accept":            "application/json",
+			"Accept-Encoding":   "gzip, deflate",
+			"Authorization":     "Bearer abc",
+			"Content-Type":      "application/json",
+			"Cookie":            "session=abc",
+			"Origin":            "https://example.com",
+			"Sec-Fetch-Mode":    "cors",
+			"User-Agent":        "curl/7.68.0",
+			"X-Forwarded-For":   "10.0.0.1",
+			"X-Forwarded-Proto": "https",
+			"X-Request-ID":      "abc",
+		} {
+			req.Header.Set(k, h)
+		}
+		for k, h := range extra {
+			req.Header.Set(k, h)
+		}
+		return req
+	}
+
+	t.Run("Standard headers accepted", func(t *testing.T) {
+		h := sv.SwaggerValidatorHTTP(api, sv.UnknownParams(sv.RejectUnknown, "header"))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, prepareRequest(nil))
+
+		assert.Equal(t, 200, w.Code)
+	})
+
+	t.Run("Undeclared header rejected", func(t *testing.T) {
+		h := sv.SwaggerValidatorHTTP(api, sv.UnknownParams(sv.RejectUnknown, "header"), sv.KnownHeaders("x-trace-id"))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, prepareRequest(map[string]string{"X-Trace-Id": "abc", "X-Other": "abc"}))
+
+		assert.Equal(t, 400, w.Code)
+		assert.JSONEq(t, `{"details":{"header.X-Other":"Unknown header parameter"},"message":"Validation error"}`, w.Body.String())
+	})
+
+	t.Run("Standard headers kept when stripping", func(t *testing.T) {
+		var header http.Header
+		h := sv.SwaggerValidatorHTTP(api, sv.UnknownParams(sv.StripUnknown, "header"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+		}))
+
+		w := httptest.NewRecorder()
+		req := prepareRequest(map[string]string{"X-Other": "abc"})
+		expected := http.Header{}
+		for k, h := range req.Header {
+			if k != "X-Other" {
+				expected[k] = h
+			}
+		}
+		h.ServeHTTP(w, req)
+
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, expected, header)
+	})
+}
+
+func TestRepeatedFormField(t *testing.T) {
+	api := swag.New(swag.Endpoints(endpoint.New("POST", "/pets", "Add a pet",
+		endpoint.FormData("count", "integer", "", "", false),
+	)))
+
+	urlencoded := func() *http.Request {
+		req, err := http.NewRequest("POST", "/pets", strings.NewReader("count=1&count=2"))
+		if err != nil {
+			log.Fatalf("Error preparing request: %s", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+	multipartForm := func() *http.Request {
+		buff := &bytes.Buffer{}
+		mw := multipart.NewWriter(buff)
+		mw.WriteField("count", "1")
+		mw.WriteField("count", "2")
+		mw.Close()
+
+		req, err := http.NewRequest("POST", "/pets", buff)
+		if err != nil {
+			log.Fatalf("Error preparing request: %s", err)
+		}
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		return req
+	}
+
+	for _, tt := range []struct {
+		description string
+		opts        []sv.Option
+		request     func() *http.Request
+	}{
+		{"urlencoded", nil, urlencoded},
+		{"multipart", nil, multipartForm},
+		{"streamed multipart", []sv.Option{sv.StreamMultipart()}, multipartForm},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			h := sv.SwaggerValidatorHTTP(api, tt.opts...)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, tt.request())
+
+			// every value is valid on its own, but a scalar field takes a single one
+			assert.Equal(t, 400, w.Code)
+			assert.JSONEq(t, `{"details":{"count":"Invalid type. Expected: integer, given: array"},"message":"Validation error"}`, w.Body.String())
+		})
+	}
+}
+
+func TestMalformedForm(t *testing.T) {
+	api := swag.New(swag.Endpoints(endpoint.New("POST", "/pets", "Add a pet",
+		endpoint.Query("limit", "integer", "", "", false),
+		endpoint.FormData("name", "string", "", "", true),
+	)))
+
+	truncated := "--xyz\r\nContent-Disposition: form-data; name=\"name\"\r\n\r\nrex"
+	unreadable := `{"code":"unreadable_body","details":{"body":"Failed to read request body"},"message":"Validation error"}`
+
+	for _, tt := range []struct {
+		description      string
+		url              string
+		contentType      string
+		body             string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			description:      "Multipart body without a boundary",
+			url:              "/pets",
+			contentType:      "multipart/form-data",
+			body:             truncated,
+			expectedStatus:   400,
+			expectedResponse: unreadable,
+		},
+		{
+			description:      "Truncated multipart body",
+			url:              "/pets",
+			contentType:      "multipart/form-data; boundary=xyz",
+			body:             truncated,
+			expectedStatus:   400,
+			expectedResponse: unreadable,
+		},
+		{
+			description:      "Malformed urlencoded body",
+			url:              "/pets",
+			contentType:      "application/x-www-form-urlencoded",
+			body:             "name=%zz",
+			expectedStatus:   400,
+			expectedResponse: unreadable,
+		},
+		{
+			description:    "Malformed query string",
+			url:            "/pets?limit=%zz",
+			contentType:    "application/x-www-form-urlencoded",
+			body:           "name=rex",
+			expectedStatus: 200,
+		},
+	} {
+		for mode, opts := range map[string][]sv.Option{"parsed": nil, "streamed": {sv.StreamMultipart()}} {
+			t.Run(mode+" "+tt.description, func(t *testing.T) {
+				h := sv.SwaggerValidatorHTTP(api, opts...)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+				req, err := http.NewRequest("POST", tt.url, strings.NewReader(tt.body))
+				if err != nil {
+					log.Fatalf("Error preparing request: %s", err)
+				}
+				req.Header.Set("Content-Type", tt.contentType)
+
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, req)
+
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				if tt.expectedResponse != "" {
+					assert.JSONEq(t, tt.expectedResponse, w.Body.String())
+				}
+			})
+		}
+	}
+}
+
+func TestMultipartMemory(t *testing.T) {
+	api := swag.New(swag.Endpoints(endpoint.New("POST", "/upload", "Upload a file",
+		endpoint.FormDataMap(map[string]swagger.Parameter{
+			"file": {Type: "file", Required: true},
+		}),
+	)))
+
+	h := sv.SwaggerValidatorHTTP(api, sv.MultipartMemory(16))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		f.Close()
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, prepareMultipartRequest("/upload", nil, map[string]string{"file": strings.Repeat("x", 1024)}))
+
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestCollectionFormat(t *testing.T) {
+	testTable := []struct {
+		description    string
+		format         string
+		query          string
+		expectedStatus int
+		expectedErrors string
+	}{
+		{
+			description:    "Comma separated by default",
+			query:          "ids=1,2,3",
+			expectedStatus: 200,
+		},
+		{
+			description:    "Repeated values by default",
+			query:          "ids=1&ids=2",
+			expectedStatus: 200,
+		},
+		{
+			description:    "csv",
+			format:         "csv",
+			query:          "ids=1,abc",
+			expectedStatus: 400,
+			expectedErrors: `{"ids.1":"Invalid type. Expected: integer, given: string"}`,
+		},
+		{
+			description:    "ssv",
+			format:         "ssv",
+			query:          "ids=1%202%203",
+			expectedStatus: 200,
+		},
+		{
+			description:    "tsv",
+			format:         "tsv",
+			query:          "ids=1%092",
+			expectedStatus: 200,
+		},
+		{
+			description:    "pipes",
+			format:         "pipes",
+			query:          "ids=1|2|3",
+			expectedStatus: 200,
+		},
+		{
+			description:    "pipes value not split on commas",
+			format:         "pipes",
+			query:          "ids=1,2",
+			expectedStatus: 400,
+			expectedErrors: `{"ids.0":"Invalid type. Expected: integer, given: string"}`,
+		},
+		{
+			description:    "multi value not split on commas",
+			format:         "multi",
+			query:          "ids=1,2&ids=3",
+			expectedStatus: 400,
+			expectedErrors: `{"ids.0":"Invalid type. Expected: integer, given: string"}`,
+		},
+		{
+			description:    "multi",
+			format:         "multi",
+			query:          "ids=1&ids=2",
+			expectedStatus: 200,
+		},
+		{
+			description:    "Unknown format ignored",
+			format:         "semicolons",
+			query:          "ids=1,2",
+			expectedStatus: 200,
+		},
+	}
+
+	api := swag.New(swag.Endpoints(endpoint.New("GET", "/pets", "List pets",
+		endpoint.QueryMap(map[string]swagger.Parameter{
+			"ids": {Type: "array", Items: &swagger.Items{Type: "integer"}},
+		}),
+	)))
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+			var opts []sv.Option
+			if tt.format != "" {
+				opts = append(opts, sv.CollectionFormat("get", "/pets", "query", "ids", tt.format))
+			}
+			h := sv.SwaggerValidatorHTTP(api, opts...)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest("GET", "/pets?"+tt.query, nil)
+			if err != nil {
+				log.Fatalf("Error preparing request: %s", err)
+			}
+
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedErrors != "" {
+				assert.JSONEq(t, `{"details":`+tt.expectedErrors+`,"message":"Validation error"}`, w.Body.String())
+			}
+		})
+	}
+}
+
+type upload struct {
+	field, filename, contentType, content string
+}
+
+func prepareUploadRequest(url string, uploads ...upload) *http.Request {
+	buff := &bytes.Buffer{}
+	mw := multipart.NewWriter(buff)
+	for _, u := range uploads {
+		h := textproto.MIMEHeader{}
+		// an upload without a filename is a plain text field
+		if u.filename == "" {
+			h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, u.field))
+		} else {
+			h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, u.field, u.filename))
+		}
+		if u.contentType != "" {
+			h.Set("Content-Type", u.contentType)
+		}
+		pw, err := mw.CreatePart(h)
+		if err != nil {
+			log.Fatalf("Failed to create file: %s", err)
+		}
+		pw.Write([]byte(u.content))
+	}
+	mw.Close()
+
+	req, err := http.NewRequest("POST", url, buff)
+	if err != nil {
+		log.Fatalf("Error preparing request: %s", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestFiles(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("x", 100)
+
+	testTable := []struct {
+		description    string
+		uploads        []upload
+		expectedStatus int
+		expectedErrors string
+	}{
+		{
+			description:    "Valid files",
+			uploads:        []upload{{"photo", "a.png", "image/png", png}, {"photo", "b.PNG", "", png}},
+			expectedStatus: 200,
+		},
+		{
+			description:    "Text field sent for a file",
+			uploads:        []upload{{"photo", "", "", "str"}},
+			expectedStatus: 400,
+			expectedErrors: `{"photo":"Invalid type. Expected: file, given: string"}`,
+		},
+		{
+			description:    "Too many files",
+			uploads:        []upload{{"photo", "a.png", "", png}, {"photo", "b.png", "", png}, {"photo", "c.png", "", png}},
+			expectedStatus: 400,
+			expectedErrors: `{"photo":"At most 2 files are allowed"}`,
+		},
+		{
+			description:    "File too small",
+			uploads:        []upload{{"photo", "a.png", "", png[:20]}},
+			expectedStatus: 400,
+			expectedErrors: `{"photo.0":"File a.png must be at least 50 bytes, given: 20"}`,
+		},
+		{
+			description:    "File too large",
+			uploads:        []upload{{"photo", "a.png", "", png}, {"photo", "b.png", "", png + strings.Repeat("x", 1000)}},
+			expectedStatus: 400,
+			expectedErrors: `{"photo.1":"File b.png must be at most 1024 bytes"}`,
+		},
+		{
+			description:    "Extension not allowed",
+			uploads:        []upload{{"photo", "a.gif", "", png}},
+			expectedStatus: 400,
+			expectedErrors: `{"photo.0":"File a.gif must have one of the following extensions: .png, .jpg"}`,
+		},
+		{
+			description:    "Declared type not allowed",
+			uploads:        []upload{{"photo", "a.png", "text/plain", png}},
+			expectedStatus: 400,
+			expectedErrors: `{"photo.0":"File a.png must be one of the following types: image/*, given: text/plain"}`,
+		},
+		{
+			description:    "Sniffed type not allowed",
+			uploads:        []upload{{"photo", "a.png", "image/png", "%PDF-1.4" + strings.Repeat("x", 100)}},
+			expectedStatus: 400,
+			expectedErrors: `{"photo.0":"File a.png must be one of the following types: image/*, given: application/pdf"}`,
+		},
+		{
+			description:    "Generic sniffed type without a declared type",
+			uploads:        []upload{{"photo", "a.png", "application/octet-stream", strings.Repeat("x", 100)}},
+			expectedStatus: 400,
+			expectedErrors: `{"photo.0":"File a.png must be one of the following types: image/*, given: text/plain"}`,
+		},
+	}
+
+	api := swag.New(swag.Endpoints(endpoint.New("POST", "/pets/{id}/photos", "Upload photos",
+		endpoint.Path("id", "integer", "", ""),
+		endpoint.FormDataMap(map[string]swagger.Parameter{
+			"photo": {Type: "file", Required: true},
+		}),
+	)))
+
+	files := sv.Files("POST", "/pets/{id}/photos", "photo", sv.FileConstraints{
+		MinSize:    50,
+		MaxSize:    1024,
+		MIMETypes:  []string{"image/*"},
+		Extensions: []string{".png", ".jpg"},
+		MaxCount:   2,
+	})
+
+	for mode, opts := range map[string][]sv.Option{"parsed": {files}, "streamed": {files, sv.StreamMultipart()}} {
+		h := sv.SwaggerValidatorHTTP(api, opts...)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+		for _, tt := range testTable {
+			t.Run(mode+" "+tt.description, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, prepareUploadRequest("/pets/1/photos", tt.uploads...))
+
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				if tt.expectedErrors != "" {
+					assert.JSONEq(t, `{"details":`+tt.expectedErrors+`,"message":"Validation error"}`, w.Body.String())
+				}
+			})
+		}
+	}
+}
+
+func TestUndetectedFileTypes(t *testing.T) {
+	testTable := []struct {
+		description    string
+		upload         upload
+		expectedStatus int
+		expectedErrors string
+	}{
+		{
+			description:    "CSV sniffed as text/plain",
+			upload:         upload{"report", "a.csv", "text/csv", "id,name\n1,Ollie\n"},
+			expectedStatus: 200,
+		},
+		{
+			description:    "JSON sniffed as text/plain",
+			upload:         upload{"report", "a.json", "application/json", `{"id": 1}`},
+			expectedStatus: 200,
+		},
+		{
+			description:    "SVG sniffed as text/xml",
+			upload:         upload{"report", "a.svg", "image/svg+xml", `<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`},
+			expectedStatus: 200,
+		},
+		{
+			description:    "DOCX sniffed as application/zip",
+			upload:         upload{"report", "a.docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", "PK\x03\x04" + strings.Repeat("x", 100)},
+			expectedStatus: 200,
+		},
+		{
+			description:    "Declared type not allowed",
+			upload:         upload{"report", "a.csv", "text/plain", "id,name\n1,Ollie\n"},
+			expectedStatus: 400,
+			expectedErrors: `{"report.0":"File a.csv must be one of the following types: text/csv, application/json, image/svg+xml, application/vnd.openxmlformats-officedocument.wordprocessingml.document, given: text/plain"}`,
+		},
+		{
+			description:    "Sniffed type contradicting the declared type",
+			upload:         upload{"report", "a.csv", "text/csv", "\x89PNG\r\n\x1a\n"},
+			expectedStatus: 400,
+			expectedErrors: `{"report.0":"File a.csv must be one of the following types: text/csv, application/json, image/svg+xml, application/vnd.openxmlformats-officedocument.wordprocessingml.document, given: image/png"}`,
+		},
+	}
+
+	api := swag.New(swag.Endpoints(endpoint.New("POST", "/reports", "Upload a report",
+		endpoint.FormDataMap(map[string]swagger.Parameter{
+			"report": {Type: "file", Required: true},
+		}),
+	)))
+
+	files := sv.Files("POST", "/reports", "report", sv.FileConstraints{
+		MIMETypes: []string{"text/csv", "application/json", "image/svg+xml", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	})
+
+	for mode, opts := range map[string][]sv.Option{"parsed": {files}, "streamed": {files, sv.StreamMultipart()}} {
+		h := sv.SwaggerValidatorHTTP(api, opts...)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+		for _, tt := range testTable {
+			t.Run(mode+" "+tt.description, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, prepareUploadRequest("/reports", tt.upload))
+
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				if tt.expectedErrors != "" {
+					assert.JSONEq(t, `{"details":`+tt.expectedErrors+`,"message":"Validation error"}`, w.Body.String())
+				}
+			})
+		}
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("body read past the rejected part")
+}
+
+func TestStreamMultipart(t *testing.T) {
+	api := swag.New(swag.Endpoints(endpoint.New("POST", "/photos", "Upload a photo",
+		endpoint.FormDataMap(map[string]swagger.Parameter{
+			"photo": {Type: "file", Required: true},
+			"count": {Type: "integer"},
+		}),
+	)))
+
+	var received string
+	h := sv.SwaggerValidatorHTTP(api, sv.StreamMultipart(), sv.UnknownParams(sv.RejectUnknown))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, _, err := r.FormFile("photo")
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+		content, _ := ioutil.ReadAll(f)
+		received = r.FormValue("count") + " " + string(content)
+	}))
+
+	t.Run("Request rebuilt for the next handler", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, prepareMultipartRequest("/photos", map[string]string{"count": "2"}, map[string]string{"photo": "content"}))
+
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "2 content", received)
+	})
+
+	for _, tt := range []struct {
+		description    string
+		fields         map[string]string
+		expectedErrors string
+	}{
+		{
+			description:    "Undeclared field rejected before the rest of the body is read",
+			fields:         map[string]string{"foo": "bar"},
+			expectedErrors: `{"foo":"Unknown form parameter"}`,
+		},
+		{
+			description:    "Mistyped field rejected before the rest of the body is read",
+			fields:         map[string]string{"count": "abc"},
+			expectedErrors: `{"count":"Invalid type. Expected: integer, given: string"}`,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			req := prepareMultipartRequest("/photos", tt.fields, map[string]string{"photo": "content"})
+			// reading past the header of the file part fails
+			body, _ := ioutil.ReadAll(req.Body)
+			body = body[:bytes.Index(body, []byte("filename="))]
+			req.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), failingReader{}))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, 400, w.Code)
+			assert.JSONEq(t, `{"details":`+tt.expectedErrors+`,"message":"Validation error"}`, w.Body.String())
+		})
+	}
+
+	t.Run("Not streamed without a body size limit", func(t *testing.T) {
+		v, err := sv.NewValidatorChecked(api, sv.StreamMultipart(), sv.MaxBodySize(0), sv.UnknownParams(sv.RejectUnknown))
+		if assert.Error(t, err) {
+			assert.Equal(t, []string{"StreamMultipart is ignored for POST /photos, which has no body size limit"}, err.(*sv.Report).InvalidOptions)
+		}
+		h := v.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+		req := prepareMultipartRequest("/photos", map[string]string{"foo": "bar"}, map[string]string{"photo": "content"})
+		body, _ := ioutil.ReadAll(req.Body)
+		body = body[:bytes.Index(body, []byte("filename="))]
+		req.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), failingReader{}))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		// the whole body is parsed, so the read error comes before the undeclared field
+		assert.Equal(t, 400, w.Code)
+		assert.JSONEq(t, `{"code":"unreadable_body","details":{"body":"Failed to read request body"},"message":"Validation error"}`, w.Body.String())
+	})
+}
+
+func TestMaxBodySize(t *testing.T) {
+	unknownLength := func(req *http.Request) *http.Request {
+		req.ContentLength = -1
+		return req
+	}
+	formRequest := func(url, body string) *http.Request {
+		req, err := http.NewRequest("POST", url, strings.NewReader(body))
+		if err != nil {
+			log.Fatalf("Error preparing request: %s", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+
+	testTable := []struct {
+		description    string
+		opts           []sv.Option
+		req            *http.Request
+		expectedStatus int
+		expectedErrors string
+	}{
+		{
+			description:    "JSON body within the limit",
+			req:            preparePostRequest("/pets", payload{FormatString: testUUID}),
+			expectedStatus: 200,
+		},
+		{
+			description:    "JSON body declared too large",
+			req:            preparePostRequest("/pets", payload{MinLenString: strings.Repeat("x", 100)}),
+			expectedStatus: 413,
+			expectedErrors: `{"body":"Request body must be at most 64 bytes"}`,
+		},
+		{
+			description:    "JSON body of unknown length too large",
+			req:            unknownLength(preparePostRequest("/pets", payload{MinLenString: strings.Repeat("x", 100)})),
+			expectedStatus: 413,
+			expectedErrors: `{"body":"Request body must be at most 64 bytes"}`,
+		},
+		{
+			description:    "Form body of unknown length too large",
+			req:            unknownLength(formRequest("/pets", "min_len_str="+strings.Repeat("x", 100))),
+			expectedStatus: 413,
+			expectedErrors: `{"body":"Request body must be at most 64 bytes"}`,
+		},
+		{
+			description:    "Multipart body within the endpoint limit",
+			req:            unknownLength(prepareMultipartRequest("/upload", nil, map[string]string{"file": strings.Repeat("x", 100)})),
+			expectedStatus: 200,
+		},
+		{
+			description:    "Multipart body of unknown length too large",
+			req:            unknownLength(prepareMultipartRequest("/upload", nil, map[string]string{"file": strings.Repeat("x", 2048)})),
+			expectedStatus: 413,
+			expectedErrors: `{"body":"Request body must be at most 1024 bytes"}`,
+		},
+		{
+			description:    "Streamed multipart body of unknown length too large",
+			opts:           []sv.Option{sv.StreamMultipart()},
+			req:            unknownLength(prepareMultipartRequest("/upload", nil, map[string]string{"file": strings.Repeat("x", 2048)})),
+			expectedStatus: 413,
+			expectedErrors: `{"body":"Request body must be at most 1024 bytes"}`,
+		},
+		{
+			description:    "No limit",
+			opts:           []sv.Option{sv.MaxBodySize(0)},
+			req:            preparePostRequest("/pets", payload{MinLenString: strings.Repeat("x", 100)}),
+			expectedStatus: 200,
+		},
+	}
+
+	api := swag.New(swag.Endpoints(
+		endpoint.New("POST", "/pets", "Add a pet",
+			endpoint.Body(payload{}, "Validation body", true),
+		),
+		endpoint.New("POST", "/upload", "Upload a file",
+			endpoint.FormDataMap(map[string]swagger.Parameter{
+				"file": {Type: "file", Required: true},
+			}),
+		),
+	))
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+			opts := append([]sv.Option{sv.MaxBodySize(64), sv.EndpointMaxBodySize("POST", "/upload", 1024)}, tt.opts...)
+			h := sv.SwaggerValidatorHTTP(api, opts...)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, tt.req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedErrors != "" {
+				assert.JSONEq(t, `{"code":"body_too_large","details":`+tt.expectedErrors+`,"message":"Validation error"}`, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package swagvalidator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labstack/echo/v4"
+	"github.com/miketonks/swag/swagger"
+)
+
+// Report lists endpoints that the middlewares cannot reliably validate.
+// Endpoints and routes are identified as "METHOD /colon/path".
+type Report struct {
+	// DuplicateHandlers maps handler names shared by several endpoints to those endpoints, see MatchHandlerName
+	DuplicateHandlers map[string][]string
+	// MissingHandlers lists endpoints declared without a handler, see MatchHandlerName
+	MissingHandlers []string
+	// UnknownRoutes lists routes registered in the router with no matching endpoint
+	UnknownRoutes []string
+	// UnroutedEndpoints lists endpoints with no matching route registered in the router
+	UnroutedEndpoints []string
+	// InvalidOptions describes the options of the Validator with invalid values or set for undeclared
+	// endpoints or parameters e.g. an unknown CollectionFormat, which are ignored
+	InvalidOptions []string
+}
+
+// Empty reports whether no problems were found
+func (r *Report) Empty() bool {
+	return len(r.DuplicateHandlers) == 0 &&
+		len(r.MissingHandlers) == 0 &&
+		len(r.UnknownRoutes) == 0 &&
+		len(r.UnroutedEndpoints) == 0 &&
+		len(r.InvalidOptions) == 0
+}
+
+// Error describes every problem found, one per line
+func (r *Report) Error() string {
+	lines := []string{}
+
+	names := make([]string, 0, len(r.DuplicateHandlers))
+	for name := range r.DuplicateHandlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("handler %s is shared by %s", name, strings.Join(r.DuplicateHandlers[name], ", ")))
+	}
+	for _, e := range r.MissingHandlers {
+		lines = append(lines, fmt.Sprintf("endpoint %s has no handler", e))
+	}
+	for _, e := range r.UnknownRoutes {
+		lines = append(lines, fmt.Sprintf("route %s has no swagger endpoint", e))
+	}
+	for _, e := range r.UnroutedEndpoints {
+		lines = append(lines, fmt.Sprintf("endpoint %s is not registered in the router", e))
+	}
+	lines = append(lines, r.InvalidOptions...)
+
+	return "swagger validator: " + strings.Join(lines, "; ")
+}
+
+// NewValidatorChecked builds a Validator like NewValidator and returns a *Report as error when options
+// are invalid, or endpoints share a handler or have no handler at all while matched by handler name, see MatchHandlerName
+func NewValidatorChecked(api *swagger.API, opts ...Option) (*Validator, error) {
+	v := NewValidator(api, opts...)
+	if report := v.Report(); !report.Empty() {
+		return v, report
+	}
+	return v, nil
+}
+
+// Report lists invalid options, endpoints sharing a handler and endpoints without a handler; the
+// latter only fail to be matched by handler name, so they are not listed without MatchHandlerName
+func (v *Validator) Report() *Report {
+	report := &Report{
+		DuplicateHandlers: map[string][]string{},
+		MissingHandlers:   []string{},
+		UnknownRoutes:     []string{},
+		UnroutedEndpoints: []string{},
+		InvalidOptions:    append([]string{}, v.invalidOptions...),
+	}
+	sort.Strings(report.InvalidOptions)
+
+	if !v.matchHandlerName {
+		return report
+	}
+
+	handlers := map[string][]string{}
+	for _, rt := range v.routes {
+		if rt.endpoint.Handler == nil {
+			report.MissingHandlers = append(report.MissingHandlers, rt.String())
+			continue
+		}
+		name := nameOfFunction(rt.endpoint.Handler)
+		handlers[name] = append(handlers[name], rt.String())
+	}
+	for name, endpoints := range handlers {
+		if len(endpoints) > 1 {
+			sort.Strings(endpoints)
+			report.DuplicateHandlers[name] = endpoints
+		}
+	}
+	sort.Strings(report.MissingHandlers)
+
+	return report
+}
+
+// ReportGin extends Report by comparing the endpoints with the routes registered in engine
+func (v *Validator) ReportGin(engine *gin.Engine) *Report {
+	routes := []string{}
+	for _, r := range engine.Routes() {
+		routes = append(routes, r.Method+" "+r.Path)
+	}
+	return v.reportRoutes(routes)
+}
+
+// ReportEcho extends Report by comparing the endpoints with the routes registered in e.
+// Only routes added through the Echo instance are known, not those added to its Router directly.
+func (v *Validator) ReportEcho(e *echo.Echo) *Report {
+	routes := []string{}
+	for _, r := range e.Routes() {
+		routes = append(routes, r.Method+" "+r.Path)
+	}
+	return v.reportRoutes(routes)
+}
+
+func (v *Validator) reportRoutes(routes []string) *Report {
+	report := v.Report()
+
+	registered := map[string]bool{}
+	for _, r := range routes {
+		registered[r] = true
+		if _, found := v.routes[strings.Replace(r, " ", "", 1)]; !found {
+			report.UnknownRoutes = append(report.UnknownRoutes, r)
+		}
+	}
+	for _, rt := range v.routes {
+		if !registered[rt.String()] {
+			report.UnroutedEndpoints = append(report.UnroutedEndpoints, rt.String())
+		}
+	}
+	sort.Strings(report.UnknownRoutes)
+	sort.Strings(report.UnroutedEndpoints)
+
+	return report
+}
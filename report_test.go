@@ -0,0 +1,143 @@
+package swagvalidator_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labstack/echo/v4"
+	swag "github.com/miketonks/swag"
+	"github.com/miketonks/swag/endpoint"
+	"github.com/stretchr/testify/assert"
+
+	sv "github.com/Rekfuki/swag-validator"
+)
+
+func sharedGinHandler(*gin.Context) {}
+
+func sharedEchoHandler(echo.Context) error { return nil }
+
+func TestNewValidatorChecked(t *testing.T) {
+	api := swag.New(swag.Endpoints(
+		endpoint.New("GET", "/pets/{id}", "Get a pet", endpoint.Handler(sharedGinHandler)),
+		endpoint.New("GET", "/owners/{id}", "Get an owner", endpoint.Handler(sharedGinHandler)),
+		endpoint.New("GET", "/toys", "List toys"),
+	))
+
+	_, err := sv.NewValidatorChecked(api, sv.MatchHandlerName())
+
+	report, ok := err.(*sv.Report)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, map[string][]string{
+		"github.com/Rekfuki/swag-validator_test.sharedGinHandler": {"GET /owners/:id", "GET /pets/:id"},
+	}, report.DuplicateHandlers)
+	assert.Equal(t, []string{"GET /toys"}, report.MissingHandlers)
+	assert.Equal(t, "swagger validator: "+
+		"handler github.com/Rekfuki/swag-validator_test.sharedGinHandler is shared by GET /owners/:id, GET /pets/:id; "+
+		"endpoint GET /toys has no handler", report.Error())
+
+	// handlers only matter to the endpoints matched by handler name
+	_, err = sv.NewValidatorChecked(api)
+	assert.Nil(t, err)
+
+	handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	api = swag.New(swag.Endpoints(
+		endpoint.New("GET", "/pets/{id}", "Get a pet", endpoint.Handler(handler)),
+		endpoint.New("GET", "/owners/{id}", "Get an owner", endpoint.Handler(handler)),
+	))
+
+	_, err = sv.NewValidatorChecked(api)
+	assert.Nil(t, err)
+
+	api = swag.New(swag.Endpoints(
+		endpoint.New("GET", "/pets/{id}", "Get a pet", endpoint.Handler(sharedGinHandler)),
+	))
+
+	_, err = sv.NewValidatorChecked(api, sv.MatchHandlerName())
+	assert.Nil(t, err)
+}
+
+func TestReportOptions(t *testing.T) {
+	api := swag.New(swag.Endpoints(
+		endpoint.New("GET", "/pets", "List pets",
+			endpoint.Query("tags", "array", "", "", false),
+		),
+		endpoint.New("POST", "/pets/{id}/photos", "Upload photos",
+			endpoint.Path("id", "integer", "", ""),
+			endpoint.FormData("photo", "file", "", "", true),
+		),
+	))
+
+	_, err := sv.NewValidatorChecked(api,
+		sv.CollectionFormat("GET", "/pets", "query", "tags", "semicolons"),
+		sv.CollectionFormat("GET", "/pets", "query", "ids", "csv"),
+		sv.CollectionFormat("GET", "/owners", "query", "ids", "csv"),
+		sv.Files("POST", "/pets/{id}/photos", "photo", sv.FileConstraints{MaxCount: 1}),
+		sv.Files("POST", "/pets/{id}/photos", "id", sv.FileConstraints{MaxCount: 1}),
+		sv.EndpointMaxBodySize("PUT", "/pets/{id}", 1024),
+	)
+
+	report, ok := err.(*sv.Report)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, []string{
+		`collectionFormat "semicolons" of parameter query.tags of GET /pets is unknown`,
+		"collectionFormat is set for undeclared parameter query.ids of GET /pets",
+		"file constraints are set for undeclared file parameter id of POST /pets/:id/photos",
+		"options are set for undeclared endpoint GET /owners",
+		"options are set for undeclared endpoint PUT /pets/:id",
+	}, report.InvalidOptions)
+
+	_, err = sv.NewValidatorChecked(api,
+		sv.CollectionFormat("get", "/pets", "query", "tags", "pipes"),
+		sv.Files("post", "/pets/{id}/photos", "photo", sv.FileConstraints{MaxCount: 1}),
+	)
+	assert.Nil(t, err)
+}
+
+func TestReportGin(t *testing.T) {
+	api := swag.New(
+		swag.BasePath("/api"),
+		swag.Endpoints(
+			endpoint.New("GET", "/pets/{id}", "Get a pet", endpoint.Handler(sharedGinHandler)),
+			endpoint.New("POST", "/pets", "Add a pet", endpoint.Handler(func(*gin.Context) {})),
+		),
+	)
+
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.GET("/api/pets/:id", sharedGinHandler)
+	r.GET("/api/owners/:id", sharedGinHandler)
+
+	report := sv.NewValidator(api).ReportGin(r)
+
+	assert.Equal(t, []string{"GET /api/owners/:id"}, report.UnknownRoutes)
+	assert.Equal(t, []string{"POST /api/pets"}, report.UnroutedEndpoints)
+	assert.False(t, report.Empty())
+}
+
+func TestReportEcho(t *testing.T) {
+	api := swag.New(
+		swag.BasePath("/api"),
+		swag.Endpoints(
+			endpoint.New("GET", "/pets/{id}", "Get a pet", endpoint.Handler(sharedEchoHandler)),
+		),
+	)
+
+	e := echo.New()
+	e.GET("/api/pets/:id", sharedEchoHandler)
+
+	report := sv.NewValidator(api).ReportEcho(e)
+
+	assert.True(t, report.Empty())
+
+	e.GET("/api/owners/:id", sharedEchoHandler)
+
+	report = sv.NewValidator(api).ReportEcho(e)
+
+	assert.Equal(t, []string{"GET /api/owners/:id"}, report.UnknownRoutes)
+	assert.Equal(t, []string{}, report.UnroutedEndpoints)
+}
@@ -1,26 +1,24 @@
 package swagvalidator
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"reflect"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/labstack/echo/v4"
-	"github.com/miketonks/swag"
 	"github.com/miketonks/swag/swagger"
-	"github.com/xeipuuv/gojsonschema"
 )
 
-// MaxMemory ...
+// MaxMemory is the default number of bytes of a multipart body kept in memory, see MultipartMemory
 const MaxMemory = 1 * 1024 * 1024
 
+// DefaultMaxBodySize is the default maximum size of a request body in bytes, see MaxBodySize
+const DefaultMaxBodySize = 10 * 1024 * 1024
+
 // RequestSchema ...
 type RequestSchema struct {
 	Title                string                      `json:"title"`
@@ -28,10 +26,17 @@ type RequestSchema struct {
 	Summary              string                      `json:"summary"`
 	Properties           map[string]interface{}      `json:"properties"`
 	Required             []string                    `json:"required"`
-	Definitions          map[string]SchemaDefinition `json:"definitions"`
+	Definitions          map[string]SchemaDefinition `json:"definitions,omitempty"`
 	AdditionalProperties bool                        `json:"additionalProperties"`
 }
 
+// RequestParameters groups the parameters declared in one location e.g. query or header
+type RequestParameters struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+	Required   []string               `json:"required,omitempty"`
+}
+
 // RequestParameter ...
 type RequestParameter struct {
 	Name                 string         `json:"name,omitempty"`
@@ -96,7 +101,19 @@ type SchemaProperty struct {
 	AdditionalProperties interface{}    `json:"additionalProperties,omitempty"`
 }
 
-func loadValueForKey(properties map[string]interface{}, key string, values []string) interface{} {
+// collectionSeparators maps the swagger collectionFormat of an array parameter to its separator
+var collectionSeparators = map[string]string{
+	"csv":   ",",
+	"ssv":   " ",
+	"tsv":   "\t",
+	"pipes": "|",
+	"multi": "",
+}
+
+// loadValueForKey coerces the values received for the parameter key to its declared type.
+// The values of an array parameter are split according to collectionFormat; without one,
+// repeated values are used as the elements and a single value is split on commas.
+func loadValueForKey(properties map[string]interface{}, key string, values []string, collectionFormat string) interface{} {
 	valueType := ""
 	valueFormat := ""
 	elemType := ""
@@ -104,18 +121,13 @@ func loadValueForKey(properties map[string]interface{}, key string, values []str
 	propI, found := properties[key]
 	if found {
 		prop := propI.(map[string]interface{})
-		t, found := prop["type"]
-		if found {
-			valueType = t.(string)
-		}
+		valueType = schemaType(prop["type"])
 		f, found := prop["format"]
 		if found {
 			valueFormat = f.(string)
 		}
 		if items, ok := prop["items"]; ok {
-			if t, ok := items.(map[string]interface{})["type"]; ok {
-				elemType = t.(string)
-			}
+			elemType = schemaType(items.(map[string]interface{})["type"])
 			if f, ok := items.(map[string]interface{})["format"]; ok {
 				elemFormat = f.(string)
 			}
@@ -127,11 +139,23 @@ func loadValueForKey(properties map[string]interface{}, key string, values []str
 		return coerce(values[0], valueType, valueFormat)
 	}
 
-	// if we received multiple values, use them as the elements; otherwise, split the value we got
 	var items []string
-	if len(values) > 1 {
+	switch {
+	case collectionFormat == "multi":
+		// every value is an element, separators included
+		result := []interface{}{}
+		for _, item := range values {
+			result = append(result, coerce(item, elemType, elemFormat))
+		}
+		return result
+	case collectionFormat != "":
+		for _, value := range values {
+			items = append(items, strings.Split(value, collectionSeparators[collectionFormat])...)
+		}
+	case len(values) > 1:
+		// if we received multiple values, use them as the elements; otherwise, split the value we got
 		items = values
-	} else {
+	default:
 		items = strings.Split(values[0], ",")
 	}
 
@@ -143,291 +167,60 @@ func loadValueForKey(properties map[string]interface{}, key string, values []str
 }
 
 // SwaggerValidator Gin middleware
-func SwaggerValidator(api *swagger.API) gin.HandlerFunc {
-
-	apiMap := map[string]gojsonschema.JSONLoader{}
-	for _, p := range api.Paths {
-		for _, e := range []*swagger.Endpoint{
-			p.Delete,
-			p.Get,
-			p.Post,
-			p.Put,
-			p.Patch,
-			p.Head,
-			p.Options,
-			p.Trace,
-			p.Connect} {
-			if e != nil && e.Handler != nil {
-				schema := buildRequestSchema(e)
-				schema.Definitions = buildSchemaDefinitions(api)
-				schemaLoader := gojsonschema.NewGoLoader(schema)
-
-				handler := nameOfFunction(e.Handler)
-				apiMap[handler] = schemaLoader
-			}
-		}
-	}
+func SwaggerValidator(api *swagger.API, opts ...Option) gin.HandlerFunc {
+	return NewValidator(api, opts...).Gin()
+}
 
+// Gin returns a Gin middleware validating requests with v
+func (v *Validator) Gin() gin.HandlerFunc {
 	// This part runs at runtime, with context for individual request
 	return func(c *gin.Context) {
-		schemaLoader, found := apiMap[c.HandlerName()]
+		rt, found := v.routes[c.Request.Method+c.FullPath()]
+		if !found && v.matchHandlerName {
+			rt, found = v.handlers[c.HandlerName()]
+		}
 		if !found {
 			c.Next()
 			return
 		}
-		ref, _ := schemaLoader.LoadJSON()
-		properties, _ := ref.(map[string]interface{})["properties"].(map[string]interface{})
-
-		document := map[string]interface{}{}
 
+		params := map[string]string{}
 		for _, p := range c.Params {
-			document[p.Key] = loadValueForKey(properties, p.Key, []string{p.Value})
-		}
-		for k, v := range c.Request.URL.Query() {
-			document[k] = loadValueForKey(properties, k, v)
+			params[p.Key] = p.Value
 		}
 
-		// For muiltipart form, handle params and file uploads
-		if c.ContentType() == "multipart/form-data" {
-			r := c.Request
-			r.ParseMultipartForm(MaxMemory)
-
-			for k, v := range c.Request.PostForm {
-				document[k] = coerce(v[0], "", "")
-			}
-			if r.MultipartForm != nil && r.MultipartForm.File != nil {
-				for k := range r.MultipartForm.File {
-					document[k] = "x"
-				}
-			}
-		} else if c.ContentType() == "application/x-www-form-urlencoded" {
-			r := c.Request
-			r.ParseForm()
-
-			body := map[string]interface{}{}
-			for k, v := range c.Request.PostForm {
-				body[k] = coerce(v[0], "", "")
-			}
-			document["body"] = body
-		} else if c.Request.ContentLength > 0 {
-			// For all other types parse body as json, if possible
-
-			// read the response body to a variable
-			var body interface{}
-			b, err := ioutil.ReadAll(c.Request.Body)
-			if err != nil {
-				c.AbortWithStatusJSON(
-					http.StatusBadRequest,
-					gin.H{
-						"message": "Validation error",
-						"details": map[string]string{
-							"body": "Failed to read request body",
-						},
-					},
-				)
-				return
-			}
-			err = json.Unmarshal(b, &body)
-			// TODO Consider different error cases: Empty Body, Invalid JSON, Form Data
-			if err != nil {
-				c.AbortWithStatusJSON(
-					http.StatusBadRequest,
-					gin.H{
-						"message": "Validation error",
-						"details": map[string]string{
-							"body": "Invalid JSON format",
-						},
-					},
-				)
-				return
-			}
-			document["body"] = body
-
-			//reset the response body to the original unread state
-			c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(b))
-		}
-
-		gojsonschema.Locale = CustomLocale{}
-
-		documentLoader := gojsonschema.NewGoLoader(document)
-		result, err := gojsonschema.Validate(schemaLoader, documentLoader)
-
-		if err != nil {
-			// fmt.Printf("ERROR: %s\n", err)
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"message": "swagger document " + err.Error(),
-			})
-
-		} else if result.Valid() {
-			// fmt.Printf("The document is valid\n")
-			c.Next()
-		} else {
-			// fmt.Printf("The document is not valid. see errors :\n")
-			errors := map[string]string{}
-			for _, err := range result.Errors() {
-				description := err.Description()
-				details := err.Details()
-
-				field := details["field"].(string)
-				if val, ok := details["property"]; ok {
-					field += "." + val.(string)
-				}
-				field = strings.TrimPrefix(field, "body.")
-				field = strings.TrimPrefix(field, "(root).")
-				errors[field] = description
-			}
-			// fmt.Printf("The document is not valid. see errors : %+v\n", errors)
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"message": "Validation error",
-				"details": errors,
-			})
+		if status, body, failed := v.check(rt, c.Request, params); failed {
+			c.AbortWithStatusJSON(status, body)
+			return
 		}
+		c.Next()
 	}
 }
 
 // SwaggerValidatorEcho middleware
-func SwaggerValidatorEcho(api *swagger.API) echo.MiddlewareFunc {
-
-	basePath := strings.TrimRight(api.BasePath, "/")
-
-	apiMap := map[string]gojsonschema.JSONLoader{}
-	for _, p := range api.Paths {
-		for _, e := range []*swagger.Endpoint{
-			p.Delete,
-			p.Get,
-			p.Post,
-			p.Put,
-			p.Patch,
-			p.Head,
-			p.Options,
-			p.Trace,
-			p.Connect} {
-			if e != nil && e.Handler != nil {
-				schema := buildRequestSchema(e)
-				schema.Definitions = buildSchemaDefinitions(api)
-				schemaLoader := gojsonschema.NewGoLoader(schema)
-
-				key := e.Method + basePath + swag.ColonPath(e.Path)
-				apiMap[key] = schemaLoader
-			}
-		}
-	}
+func SwaggerValidatorEcho(api *swagger.API, opts ...Option) echo.MiddlewareFunc {
+	return NewValidator(api, opts...).Echo()
+}
 
+// Echo returns an Echo middleware validating requests with v
+func (v *Validator) Echo() echo.MiddlewareFunc {
 	// This part runs at runtime, with context for individual request
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			key := c.Request().Method + c.Path()
-			schemaLoader, found := apiMap[key]
+			rt, found := v.routes[c.Request().Method+c.Path()]
 			if !found {
 				return next(c)
 			}
-			ref, _ := schemaLoader.LoadJSON()
-			properties, _ := ref.(map[string]interface{})["properties"].(map[string]interface{})
-
-			document := map[string]interface{}{}
 
+			params := map[string]string{}
 			for _, key := range c.ParamNames() {
-				document[key] = loadValueForKey(properties, key, []string{c.Param(key)})
-			}
-			for k, v := range c.Request().URL.Query() {
-				document[k] = loadValueForKey(properties, k, v)
+				params[key] = c.Param(key)
 			}
 
-			// For muiltipart form, handle params and file uploads
-			if c.Request().Header.Get(echo.HeaderContentType) == "multipart/form-data" {
-				r := c.Request()
-				r.ParseMultipartForm(MaxMemory)
-
-				for k, v := range c.Request().PostForm {
-					document[k] = coerce(v[0], "", "")
-				}
-				if r.MultipartForm != nil && r.MultipartForm.File != nil {
-					for k := range r.MultipartForm.File {
-						document[k] = "x"
-					}
-				}
-			} else if c.Request().Header.Get(echo.HeaderContentType) == "application/x-www-form-urlencoded" {
-				r := c.Request()
-				r.ParseForm()
-
-				body := map[string]interface{}{}
-				for k, v := range c.Request().PostForm {
-					body[k] = coerce(v[0], "", "")
-				}
-				document["body"] = body
-			} else if c.Request().ContentLength > 0 {
-				// For all other types parse body as json, if possible
-
-				// read the response body to a variable
-				var body interface{}
-				b, err := ioutil.ReadAll(c.Request().Body)
-				if err != nil {
-					return c.JSON(
-						http.StatusBadRequest,
-						echo.Map{
-							"message": "Validation error",
-							"details": map[string]string{
-								"body": "Failed to read request body",
-							},
-						},
-					)
-				}
-				err = json.Unmarshal(b, &body)
-				// TODO Consider different error cases: Empty Body, Invalid JSON, Form Data
-				if err != nil {
-					return c.JSON(
-						http.StatusBadRequest,
-						echo.Map{
-							"message": "Validation error",
-							"details": map[string]string{
-								"body": "Invalid JSON format",
-							},
-						},
-					)
-				}
-				document["body"] = body
-
-				//reset the response body to the original unread state
-				c.Request().Body = ioutil.NopCloser(bytes.NewBuffer(b))
-			}
-
-			gojsonschema.Locale = CustomLocale{}
-
-			documentLoader := gojsonschema.NewGoLoader(document)
-			result, err := gojsonschema.Validate(schemaLoader, documentLoader)
-
-			if err != nil {
-				// fmt.Printf("ERROR: %s\n", err)
-				return c.JSON(http.StatusInternalServerError, echo.Map{
-					"message": "swagger document " + err.Error(),
-				})
-
-			} else if result.Valid() {
-				// fmt.Printf("The document is valid\n")
-				return next(c)
-			} else {
-				// fmt.Printf("The document is not valid. see errors :\n")
-				errors := map[string]string{}
-				for _, err := range result.Errors() {
-					description := err.Description()
-					details := err.Details()
-
-					field := details["field"].(string)
-					if val, ok := details["property"]; ok {
-						field += "." + val.(string)
-					}
-					field = strings.TrimPrefix(field, "body.")
-					field = strings.TrimPrefix(field, "(root).")
-					errors[field] = description
-				}
-				// fmt.Printf("The document is not valid. see errors : %+v\n", errors)
-				return c.JSON(http.StatusBadRequest, echo.Map{
-					"message": "Validation error",
-					"details": errors,
-				})
+			if status, body, failed := v.check(rt, c.Request(), params); failed {
+				return c.JSON(status, body)
 			}
-
-			return nil
+			return next(c)
 		}
 	}
 }
@@ -470,70 +263,132 @@ func coerce(value string, valueType string, valueFormat string) interface{} {
 	return value
 }
 
+// schemaType returns the type of a schema, which definitions list with null when nullable e.g. ["integer", "null"]
+func schemaType(t interface{}) string {
+	switch t := t.(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, t := range t {
+			if t, ok := t.(string); ok && t != "null" {
+				return t
+			}
+		}
+	}
+	return ""
+}
+
 func nameOfFunction(f interface{}) string {
 	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
 }
 
+// buildRequestSchema builds the schema of the request document, which holds the parameters
+// of each location under its own key: path, query, header, cookie, formData and body.
+// Undeclared parameters are left out of the document, see UnknownParams.
 func buildRequestSchema(e *swagger.Endpoint) *RequestSchema {
 	r := RequestSchema{
-		Title:      fmt.Sprintf("%s %s", e.Method, e.Path),
-		Type:       "object",
-		Properties: make(map[string]interface{}),
-		Required:   []string{},
+		Title:                fmt.Sprintf("%s %s", e.Method, e.Path),
+		Type:                 "object",
+		Properties:           make(map[string]interface{}),
+		Required:             []string{},
+		AdditionalProperties: true,
 	}
 
-	if len(e.Parameters) == 0 {
-		return &r
+	groups := map[string]*RequestParameters{}
+	for _, in := range []string{"path", "query", "formData"} {
+		groups[in] = &RequestParameters{
+			Type:       "object",
+			Properties: map[string]interface{}{},
+			Required:   []string{},
+		}
 	}
 
 	for _, p := range e.Parameters {
-		if p.Required {
-			r.Required = append(r.Required, p.Name)
+		if p.Name == "" {
+			continue
 		}
 
-		if p.Name != "" && p.Schema != nil {
-			r.Properties[p.Name] = p.Schema
+		if p.In == "body" {
+			r.Properties["body"] = buildParameter(p)
+			if p.Required {
+				r.Required = append(r.Required, "body")
+			}
+			continue
+		}
 
-			// TODO Consider if we should use Ref to optimise definitions
-			// if p.Schema.Ref != "" {
-			// 	parts := strings.Split(p.Schema.Ref, "/")
-			// 	last := parts[len(parts)-1]
-			//
-			// 	fmt.Printf("DEF: %+v", defs[last])
-			// 	//r.Properties[p.Name] = defs[last]
-			// }
+		in := p.In
+		if in == "" {
+			in = "query"
+		}
+		group, found := groups[in]
+		if !found {
+			group = &RequestParameters{
+				Type:       "object",
+				Properties: map[string]interface{}{},
+				Required:   []string{},
+			}
+			groups[in] = group
+		}
+		group.Properties[p.Name] = buildParameter(p)
+		if p.Required {
+			group.Required = append(group.Required, p.Name)
+		}
+	}
 
-		} else if p.Name != "" {
+	for in, group := range groups {
+		r.Properties[in] = group
+		r.Required = append(r.Required, in)
+	}
+	sort.Strings(r.Required)
 
-			param := RequestParameter{
-				Name:                 p.Name,
-				Type:                 p.Type,
-				Format:               p.Format,
-				Nullable:             p.Nullable,
-				Items:                p.Items,
-				Enum:                 p.Enum,
-				Pattern:              p.Pattern,
-				MinItems:             p.MinItems,
-				MaxItems:             p.MaxItems,
-				UniqueItems:          p.UniqueItems,
-				MinLength:            p.MinLength,
-				MaxLength:            p.MaxLength,
-				Minimum:              p.Minimum,
-				Maximum:              p.Maximum,
-				ExclusiveMinimum:     p.ExclusiveMinimum,
-				ExclusiveMaximum:     p.ExclusiveMaximum,
-				AdditionalProperties: p.AdditionalProperties,
-			}
-			// for validation purposes, file can be treated as string type
-			if p.Type == "file" {
-				param.Type = "string"
-			}
+	return &r
+}
 
-			r.Properties[p.Name] = param
+func buildParameter(p swagger.Parameter) interface{} {
+	if p.Schema != nil {
+		// definitions are shared by all endpoints, see definitionsURL
+		schema := *p.Schema
+		schema.Ref = definitionRef(schema.Ref)
+		if schema.Items != nil {
+			items := *schema.Items
+			items.Ref = definitionRef(items.Ref)
+			schema.Items = &items
 		}
+		return schema
 	}
 
-	return &r
+	param := RequestParameter{
+		Name:                 p.Name,
+		Type:                 p.Type,
+		Format:               p.Format,
+		Nullable:             p.Nullable,
+		Items:                p.Items,
+		Enum:                 p.Enum,
+		Pattern:              p.Pattern,
+		MinItems:             p.MinItems,
+		MaxItems:             p.MaxItems,
+		UniqueItems:          p.UniqueItems,
+		MinLength:            p.MinLength,
+		MaxLength:            p.MaxLength,
+		Minimum:              p.Minimum,
+		Maximum:              p.Maximum,
+		ExclusiveMinimum:     p.ExclusiveMinimum,
+		ExclusiveMaximum:     p.ExclusiveMaximum,
+		AdditionalProperties: p.AdditionalProperties,
+	}
+	// for validation purposes, file can be treated as string type
+	if p.Type == "file" {
+		param.Type = "string"
+	}
+	return param
+}
+
+// definitionRef points a local definition reference e.g. #/definitions/Pet at the shared definitions document
+func definitionRef(ref string) string {
+	if strings.HasPrefix(ref, "#/definitions/") {
+		return definitionsURL + ref
+	}
+	return ref
 }
 
 func buildSchemaDefinitions(api *swagger.API) map[string]SchemaDefinition {
@@ -584,3 +439,28 @@ func buildSchemaDefinitions(api *swagger.API) map[string]SchemaDefinition {
 	}
 	return defs
 }
+
+// buildIntegerKinds returns the Go kind of the integer properties of the api definitions, by definition and
+// property name; the kind of an array property is the kind of its items
+func buildIntegerKinds(api *swagger.API) map[string]map[string]reflect.Kind {
+	kinds := map[string]map[string]reflect.Kind{}
+	for _, d := range api.Definitions {
+		for k, p := range d.Properties {
+			t := p.GoType
+			for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+				t = t.Elem()
+			}
+			if t == nil {
+				continue
+			}
+			if _, found := integerRanges[t.Kind()]; !found {
+				continue
+			}
+			if kinds[d.Name] == nil {
+				kinds[d.Name] = map[string]reflect.Kind{}
+			}
+			kinds[d.Name][k] = t.Kind()
+		}
+	}
+	return kinds
+}
@@ -0,0 +1,69 @@
+package swagvalidator
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/miketonks/swag/swagger"
+)
+
+// SwaggerValidatorChi chi middleware
+func SwaggerValidatorChi(api *swagger.API, opts ...Option) func(http.Handler) http.Handler {
+	v := NewValidator(api, opts...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rctx, _ := r.Context().Value(chi.RouteCtxKey).(*chi.Context)
+			if rctx == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rt, params := v.chiRoute(rctx, r)
+			if rt == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if status, body, failed := v.check(rt, r, params); failed {
+				writeJSON(w, status, body)
+			} else {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// chiRoute finds the route for the chi pattern matching r. Middlewares mounted with
+// Use run before chi has routed the request, in which case the route tree is searched
+// up front the same way chi will search it afterwards.
+func (v *Validator) chiRoute(rctx *chi.Context, r *http.Request) (*route, map[string]string) {
+	if rt, found := v.routes[r.Method+templateColonPath(rctx.RoutePattern())]; found {
+		return rt, chiParams(rctx)
+	}
+	if rctx.Routes == nil {
+		return nil, nil
+	}
+
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+
+	tctx := chi.NewRouteContext()
+	if !rctx.Routes.Match(tctx, r.Method, path) {
+		return nil, nil
+	}
+	if rt, found := v.routes[r.Method+templateColonPath(tctx.RoutePattern())]; found {
+		return rt, chiParams(tctx)
+	}
+	return nil, nil
+}
+
+func chiParams(rctx *chi.Context) map[string]string {
+	params := map[string]string{}
+	for i, k := range rctx.URLParams.Keys {
+		params[k] = rctx.URLParams.Values[i]
+	}
+	return params
+}
@@ -6,9 +6,11 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
-	"github.com/labstack/echo"
+	echov3 "github.com/labstack/echo"
+	"github.com/labstack/echo/v4"
 	swag "github.com/miketonks/swag"
 	"github.com/miketonks/swag/endpoint"
 	"github.com/miketonks/swag/swagger"
@@ -21,10 +23,16 @@ func createEngineEcho(api *swagger.API) (r *echo.Echo) {
 	r = echo.New()
 	r.Use(sv.SwaggerValidatorEcho(api))
 	api.Walk(func(path string, endpoint *swagger.Endpoint) {
-		h := endpoint.Handler.(func(echo.Context) error)
-		path = swag.ColonPath(path)
+		r.Router().Add(endpoint.Method, swag.ColonPath(path), handler)
+	})
+	return
+}
 
-		r.Router().Add(endpoint.Method, path, h)
+func createEngineEchoV3(api *swagger.API) (r *echov3.Echo) {
+	r = echov3.New()
+	r.Use(sv.SwaggerValidatorEchoV3(api))
+	api.Walk(func(path string, endpoint *swagger.Endpoint) {
+		r.Router().Add(endpoint.Method, swag.ColonPath(path), func(echov3.Context) error { return nil })
 	})
 	return
 }
@@ -33,6 +41,23 @@ func handler(c echo.Context) error {
 	return nil
 }
 
+// runEcho runs test against Echo v4 and Echo v3 engines serving api
+func runEcho(t *testing.T, api *swagger.API, test func(t *testing.T, r http.Handler)) {
+	engines := []struct {
+		name string
+		r    http.Handler
+	}{
+		{"v4", createEngineEcho(api)},
+		{"v3", createEngineEchoV3(api)},
+	}
+	for _, e := range engines {
+		e := e
+		t.Run(e.name, func(t *testing.T) {
+			test(t, e.r)
+		})
+	}
+}
+
 func TestQueryEcho(t *testing.T) {
 	testTable := []struct {
 		description      string
@@ -79,7 +104,6 @@ func TestQueryEcho(t *testing.T) {
 	}
 
 	api := swag.New(swag.Endpoints(endpoint.New("GET", "/validate-test", "Test query params",
-		endpoint.Handler(handler),
 		endpoint.QueryMap(map[string]swagger.Parameter{
 			"int_param": {
 				Type: "integer",
@@ -95,36 +119,36 @@ func TestQueryEcho(t *testing.T) {
 		}),
 	)))
 
-	r := createEngineEcho(api)
+	runEcho(t, api, func(t *testing.T, r http.Handler) {
+		for _, tt := range testTable {
+			t.Run(tt.description, func(t *testing.T) {
 
-	for _, tt := range testTable {
-		t.Run(tt.description, func(t *testing.T) {
+				w := httptest.NewRecorder()
 
-			w := httptest.NewRecorder()
+				url := fmt.Sprintf("/validate-test?%s", tt.query)
 
-			url := fmt.Sprintf("/validate-test?%s", tt.query)
+				req, err := http.NewRequest("GET", url, nil)
+				if err != nil {
+					log.Fatalf("Error preparing request: %s", err)
+				}
 
-			req, err := http.NewRequest("GET", url, nil)
-			if err != nil {
-				log.Fatalf("Error preparing request: %s", err)
-			}
+				r.ServeHTTP(w, req)
 
-			r.ServeHTTP(w, req)
+				var body map[string]interface{}
 
-			var body map[string]interface{}
+				if w.Body != nil && w.Body.String() != "" {
+					err := json.Unmarshal(w.Body.Bytes(), &body)
+					if err != nil {
+						panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+					}
 
-			if w.Body != nil && w.Body.String() != "" {
-				err := json.Unmarshal(w.Body.Bytes(), &body)
-				if err != nil {
-					panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+					assert.Equal(t, tt.expectedResponse, body["details"])
 				}
 
-				assert.Equal(t, tt.expectedResponse, body["details"])
-			}
-
-			assert.Equal(t, tt.expectedStatus, w.Code)
-		})
-	}
+				assert.Equal(t, tt.expectedStatus, w.Code)
+			})
+		}
+	})
 }
 
 func TestPathEcho(t *testing.T) {
@@ -177,46 +201,43 @@ func TestPathEcho(t *testing.T) {
 			}},
 	}
 
-	// Can't bind multiple endpoints to the same handler
-	// Even if the handler is a lambda, it still does not work.
-	// Therefore have to create a new api for each endpoint iteratively
+	// Create a new api for each endpoint iteratively
 	for _, testCase := range testTable {
 		api := swag.New(
 			swag.Endpoints(endpoint.New("GET", "/validate-test"+testCase.urlWParm, "Test the validator",
-				endpoint.Handler(func(echo.Context) error { return nil }),
 				testCase.path,
 			)))
 
-		r := createEngineEcho(api)
+		runEcho(t, api, func(t *testing.T, r http.Handler) {
+			for _, tt := range testCase.cases {
+				t.Run(tt.description, func(t *testing.T) {
 
-		for _, tt := range testCase.cases {
-			t.Run(tt.description, func(t *testing.T) {
+					w := httptest.NewRecorder()
 
-				w := httptest.NewRecorder()
+					url := fmt.Sprintf("/validate-test%s/%s", testCase.url, tt.pathParam)
 
-				url := fmt.Sprintf("/validate-test%s/%s", testCase.url, tt.pathParam)
+					req, err := http.NewRequest("GET", url, nil)
+					if err != nil {
+						log.Fatalf("Error preparing request: %s", err)
+					}
 
-				req, err := http.NewRequest("GET", url, nil)
-				if err != nil {
-					log.Fatalf("Error preparing request: %s", err)
-				}
+					r.ServeHTTP(w, req)
 
-				r.ServeHTTP(w, req)
+					var body map[string]interface{}
 
-				var body map[string]interface{}
+					if w.Body != nil && w.Body.String() != "" {
+						err := json.Unmarshal(w.Body.Bytes(), &body)
+						if err != nil {
+							panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+						}
 
-				if w.Body != nil && w.Body.String() != "" {
-					err := json.Unmarshal(w.Body.Bytes(), &body)
-					if err != nil {
-						panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+						assert.Equal(t, tt.expectedResponse, body["details"])
 					}
 
-					assert.Equal(t, tt.expectedResponse, body["details"])
-				}
-
-				assert.Equal(t, tt.expectedStatus, w.Code)
-			})
-		}
+					assert.Equal(t, tt.expectedStatus, w.Code)
+				})
+			}
+		})
 	}
 }
 
@@ -451,31 +472,158 @@ func TestPayloadEcho(t *testing.T) {
 	}
 
 	api := swag.New(swag.Endpoints(endpoint.New("POST", "/validate-test", "Test the validator",
-		endpoint.Handler(func(echo.Context) error { return nil }),
 		endpoint.Body(payload{}, "Validation body", true),
 	)))
 
-	r := createEngineEcho(api)
+	runEcho(t, api, func(t *testing.T, r http.Handler) {
+		for _, tt := range testTable {
+			t.Run(tt.description, func(t *testing.T) {
 
-	for _, tt := range testTable {
-		t.Run(tt.description, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				req := preparePostRequest("/validate-test", tt.in)
+				r.ServeHTTP(w, req)
 
-			w := httptest.NewRecorder()
-			req := preparePostRequest("/validate-test", tt.in)
-			r.ServeHTTP(w, req)
+				var body map[string]interface{}
 
-			var body map[string]interface{}
+				if w.Body != nil && w.Body.String() != "" {
+					err := json.Unmarshal(w.Body.Bytes(), &body)
+					if err != nil {
+						panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+					}
 
-			if w.Body != nil && w.Body.String() != "" {
-				err := json.Unmarshal(w.Body.Bytes(), &body)
-				if err != nil {
-					panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+					assert.Equal(t, tt.expectedResponse, body["details"])
 				}
 
-				assert.Equal(t, tt.expectedResponse, body["details"])
+				assert.Equal(t, tt.expectedStatus, w.Code)
+			})
+		}
+	})
+}
+
+func BenchmarkPayloadEcho(b *testing.B) {
+	api := swag.New(swag.Endpoints(endpoint.New("POST", "/validate-test", "Test the validator",
+		endpoint.Body(payload{}, "Validation body", true),
+	)))
+
+	r := createEngineEcho(api)
+	in := payload{FormatString: testUUID, MinLenString: "123456", Nested: &nested{Foo: "bar"}}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, preparePostRequest("/validate-test", in))
+		if w.Code != 200 {
+			b.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestContentTypeEcho(t *testing.T) {
+	// requests are built per engine, as their bodies can only be read once
+	jsonRequest := func(contentType, body string) func() *http.Request {
+		return func() *http.Request {
+			req, err := http.NewRequest("POST", "/json-test", strings.NewReader(body))
+			if err != nil {
+				log.Fatalf("Error preparing request: %s", err)
 			}
+			req.Header.Set("Content-Type", contentType)
+			return req
+		}
+	}
 
-			assert.Equal(t, tt.expectedStatus, w.Code)
-		})
+	multipartRequest := func(fields map[string]string) func() *http.Request {
+		return func() *http.Request {
+			return prepareMultipartRequest("/multipart-test", fields, nil)
+		}
+	}
+
+	upperMultipart := func() *http.Request {
+		req := multipartRequest(map[string]string{"count": "abc"})()
+		req.Header.Set("Content-Type", strings.Replace(req.Header.Get("Content-Type"), "multipart/form-data", "Multipart/Form-Data", 1))
+		return req
 	}
+
+	testTable := []struct {
+		description      string
+		req              func() *http.Request
+		expectedStatus   int
+		expectedResponse map[string]interface{}
+	}{
+		{
+			description:    "Upper case multipart",
+			req:            upperMultipart,
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"count": "Invalid type. Expected: integer, given: string",
+			},
+		},
+		{
+			description:      "Multipart with boundary",
+			req:              multipartRequest(map[string]string{"count": "10"}),
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+		{
+			description:    "Invalid multipart field",
+			req:            multipartRequest(map[string]string{"count": "abc"}),
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"count": "Invalid type. Expected: integer, given: string",
+			},
+		},
+		{
+			description:    "Charset suffixed json",
+			req:            jsonRequest("application/json; charset=utf-8", `{"format_str":"abc"}`),
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"format_str": "Field does not match format 'uuid'",
+			},
+		},
+		{
+			description:    "Vendor json",
+			req:            jsonRequest("application/vnd.api+json", `{"format_str":"abc"}`),
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"format_str": "Field does not match format 'uuid'",
+			},
+		},
+		{
+			description:      "Valid charset suffixed json",
+			req:              jsonRequest("Application/JSON;charset=UTF-8", `{"format_str":"`+testUUID+`"}`),
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+	}
+
+	api := swag.New(swag.Endpoints(
+		endpoint.New("POST", "/multipart-test", "Test multipart",
+			endpoint.FormData("count", "integer", "", "", true),
+		),
+		endpoint.New("POST", "/json-test", "Test json",
+			endpoint.Body(payload{}, "Validation body", true),
+		),
+	))
+
+	runEcho(t, api, func(t *testing.T, r http.Handler) {
+		for _, tt := range testTable {
+			t.Run(tt.description, func(t *testing.T) {
+
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, tt.req())
+
+				var body map[string]interface{}
+
+				if w.Body != nil && w.Body.String() != "" {
+					err := json.Unmarshal(w.Body.Bytes(), &body)
+					if err != nil {
+						panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+					}
+
+					assert.Equal(t, tt.expectedResponse, body["details"])
+				}
+
+				assert.Equal(t, tt.expectedStatus, w.Code)
+			})
+		}
+	})
 }
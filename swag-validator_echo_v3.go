@@ -0,0 +1,31 @@
+package swagvalidator
+
+import (
+	echov3 "github.com/labstack/echo"
+	"github.com/miketonks/swag/swagger"
+)
+
+// SwaggerValidatorEchoV3 Echo v3 middleware
+func SwaggerValidatorEchoV3(api *swagger.API, opts ...Option) echov3.MiddlewareFunc {
+	v := NewValidator(api, opts...)
+
+	// This part runs at runtime, with context for individual request
+	return func(next echov3.HandlerFunc) echov3.HandlerFunc {
+		return func(c echov3.Context) error {
+			rt, found := v.routes[c.Request().Method+c.Path()]
+			if !found {
+				return next(c)
+			}
+
+			params := map[string]string{}
+			for _, key := range c.ParamNames() {
+				params[key] = c.Param(key)
+			}
+
+			if status, body, failed := v.check(rt, c.Request(), params); failed {
+				return c.JSON(status, body)
+			}
+			return next(c)
+		}
+	}
+}
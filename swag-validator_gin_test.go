@@ -6,6 +6,8 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -475,3 +477,586 @@ func TestPayloadGin(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteMatchingGin(t *testing.T) {
+	testTable := []struct {
+		description    string
+		opts           []sv.Option
+		url            string
+		expectedStatus int
+	}{
+		{
+			description:    "First route sharing a handler is validated",
+			url:            "/api/pets/abc",
+			expectedStatus: 400,
+		},
+		{
+			description:    "Second route sharing a handler is validated",
+			url:            "/api/owners/abc",
+			expectedStatus: 400,
+		},
+		{
+			description:    "Route with a handler produced by a factory is validated",
+			url:            "/api/toys/abc",
+			expectedStatus: 400,
+		},
+		{
+			description:    "Route registered outside the spec is not validated by default",
+			url:            "/legacy/abc",
+			expectedStatus: 200,
+		},
+		{
+			description:    "Route registered outside the spec is validated by handler name when enabled",
+			opts:           []sv.Option{sv.MatchHandlerName()},
+			url:            "/legacy/abc",
+			expectedStatus: 400,
+		},
+	}
+
+	shared := func(*gin.Context) {}
+	factory := func() gin.HandlerFunc {
+		return func(*gin.Context) {}
+	}
+	legacy := func(*gin.Context) {}
+
+	api := swag.New(
+		swag.BasePath("/api"),
+		swag.Endpoints(
+			endpoint.New("GET", "/pets/{id}", "Get a pet",
+				endpoint.Handler(shared),
+				endpoint.Path("id", "integer", "", ""),
+			),
+			endpoint.New("GET", "/owners/{id}", "Get an owner",
+				endpoint.Handler(shared),
+				endpoint.Path("id", "integer", "", ""),
+			),
+			endpoint.New("GET", "/toys/{id}", "Get a toy",
+				endpoint.Handler(factory()),
+				endpoint.Path("id", "integer", "", ""),
+			),
+			endpoint.New("GET", "/legacy/{id}", "Get a legacy resource",
+				endpoint.Handler(legacy),
+				endpoint.Path("id", "integer", "", ""),
+			),
+		),
+	)
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			r := gin.New()
+			r.Use(sv.SwaggerValidator(api, tt.opts...))
+			r.GET("/api/pets/:id", shared)
+			r.GET("/api/owners/:id", shared)
+			r.GET("/api/toys/:id", factory())
+			r.GET("/legacy/:id", legacy)
+
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest("GET", tt.url, nil)
+			if err != nil {
+				log.Fatalf("Error preparing request: %s", err)
+			}
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func BenchmarkPayloadGin(b *testing.B) {
+	api := swag.New(swag.Endpoints(endpoint.New("POST", "/validate-test", "Test the validator",
+		endpoint.Handler(func(*gin.Context) {}),
+		endpoint.Body(payload{}, "Validation body", true),
+	)))
+
+	r := createEngineGin(api)
+	in := payload{FormatString: testUUID, MinLenString: "123456", Nested: &nested{Foo: "bar"}}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, preparePostRequest("/validate-test", in))
+		if w.Code != 200 {
+			b.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestHeaderGin(t *testing.T) {
+	testTable := []struct {
+		description      string
+		headers          map[string]string
+		expectedStatus   int
+		expectedResponse map[string]interface{}
+	}{
+		{
+			description:    "Missing required header",
+			headers:        map[string]string{},
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"header.X-Request-ID": "X-Request-ID is required",
+			},
+		},
+		{
+			description:    "Non-int value in an int header",
+			headers:        map[string]string{"X-Request-ID": testUUID, "X-Page-Size": "abc"},
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"header.X-Page-Size": "Invalid type. Expected: integer, given: string",
+			},
+		},
+		{
+			description:      "Headers are matched case-insensitively",
+			headers:          map[string]string{"x-request-id": testUUID, "x-page-size": "10"},
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+		{
+			description:      "Undeclared headers are allowed",
+			headers:          map[string]string{"X-Request-ID": testUUID, "X-Other": "abc"},
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+	}
+
+	api := swag.New(swag.Endpoints(endpoint.New("GET", "/validate-test", "Test header params",
+		endpoint.Handler(func(*gin.Context) {}),
+		endpoint.RequestHeader("X-Request-ID", "string", "uuid", "", true),
+		endpoint.RequestHeader("X-Page-Size", "integer", "", "", false),
+	)))
+
+	r := createEngineGin(api)
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest("GET", "/validate-test", nil)
+			if err != nil {
+				log.Fatalf("Error preparing request: %s", err)
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			r.ServeHTTP(w, req)
+
+			var body map[string]interface{}
+
+			if w.Body != nil && w.Body.String() != "" {
+				err := json.Unmarshal(w.Body.Bytes(), &body)
+				if err != nil {
+					panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+				}
+
+				assert.Equal(t, tt.expectedResponse, body["details"])
+			}
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestCookieGin(t *testing.T) {
+	testTable := []struct {
+		description      string
+		cookies          map[string]string
+		expectedStatus   int
+		expectedResponse map[string]interface{}
+	}{
+		{
+			description:    "Missing required cookie",
+			cookies:        map[string]string{},
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"cookie.session": "session is required",
+			},
+		},
+		{
+			description:    "Cookie does not match pattern",
+			cookies:        map[string]string{"session": "abc"},
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"cookie.session": "Does not match pattern '^[0-9a-f]{8}$'",
+			},
+		},
+		{
+			description:    "Non-int value in an int cookie",
+			cookies:        map[string]string{"session": "0123abcd", "visits": "abc"},
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"cookie.visits": "Invalid type. Expected: integer, given: string",
+			},
+		},
+		{
+			description:    "Not allowed enum value in enum cookie",
+			cookies:        map[string]string{"session": "0123abcd", "theme": "blue"},
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"cookie.theme": "Must be one of the following: \"dark\", \"light\"",
+			},
+		},
+		{
+			description:      "Valid cookies",
+			cookies:          map[string]string{"session": "0123abcd", "visits": "10", "theme": "dark", "other": "x"},
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+	}
+
+	api := swag.New(swag.Endpoints(endpoint.New("GET", "/validate-test", "Test cookie params",
+		endpoint.Handler(func(*gin.Context) {}),
+		sv.CookieMap(map[string]swagger.Parameter{
+			"session": {Type: "string", Pattern: "^[0-9a-f]{8}$", Required: true},
+			"theme":   {Type: "string", Enum: []string{"dark", "light"}},
+		}),
+		sv.Cookie("visits", "integer", "", "", false),
+	)))
+
+	r := createEngineGin(api)
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest("GET", "/validate-test", nil)
+			if err != nil {
+				log.Fatalf("Error preparing request: %s", err)
+			}
+			for k, v := range tt.cookies {
+				req.AddCookie(&http.Cookie{Name: k, Value: v})
+			}
+
+			r.ServeHTTP(w, req)
+
+			var body map[string]interface{}
+
+			if w.Body != nil && w.Body.String() != "" {
+				err := json.Unmarshal(w.Body.Bytes(), &body)
+				if err != nil {
+					panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+				}
+
+				assert.Equal(t, tt.expectedResponse, body["details"])
+			}
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestParameterLocationGin(t *testing.T) {
+	testTable := []struct {
+		description      string
+		url              string
+		form             url.Values
+		expectedStatus   int
+		expectedResponse map[string]interface{}
+	}{
+		{
+			description:      "Parameters in their declared locations",
+			url:              "/validate-test/10?id=abc",
+			form:             url.Values{"name": {"rex"}},
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+		{
+			description:    "Query param does not override a path param",
+			url:            "/validate-test/abc?id=10",
+			form:           url.Values{"name": {"rex"}},
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"path.id": "Invalid type. Expected: integer, given: string",
+			},
+		},
+		{
+			description:    "Query param does not satisfy a form field",
+			url:            "/validate-test/10?id=abc&name=rex",
+			form:           url.Values{},
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"formData.name": "name is required",
+			},
+		},
+		{
+			description:    "Errors of the same name in every location",
+			url:            "/validate-test/abc",
+			form:           url.Values{},
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"path.id":       "Invalid type. Expected: integer, given: string",
+				"query.id":      "id is required",
+				"formData.name": "name is required",
+			},
+		},
+		{
+			description:    "Errors of a name declared once",
+			url:            "/validate-test/10?id=abc&name=rex",
+			form:           url.Values{"name": {"rex"}, "age": {"old"}},
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"age": "Invalid type. Expected: integer, given: string",
+			},
+		},
+	}
+
+	api := swag.New(swag.Endpoints(endpoint.New("POST", "/validate-test/{id}", "Test parameter locations",
+		endpoint.Handler(func(*gin.Context) {}),
+		endpoint.Path("id", "integer", "", ""),
+		endpoint.Query("id", "string", "", "", true),
+		endpoint.Query("name", "string", "", "", false),
+		endpoint.FormData("name", "string", "", "", true),
+		endpoint.FormData("age", "integer", "", "", false),
+	)))
+
+	r := createEngineGin(api)
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest("POST", tt.url, strings.NewReader(tt.form.Encode()))
+			if err != nil {
+				log.Fatalf("Error preparing request: %s", err)
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			r.ServeHTTP(w, req)
+
+			var body map[string]interface{}
+
+			if w.Body != nil && w.Body.String() != "" {
+				err := json.Unmarshal(w.Body.Bytes(), &body)
+				if err != nil {
+					panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+				}
+
+				assert.Equal(t, tt.expectedResponse, body["details"])
+			}
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+type ownerFilter struct {
+	ID int `json:"id,omitempty"`
+}
+
+type listFilter struct {
+	Status string       `json:"status,omitempty" enum:"active,archived"`
+	Limit  int          `json:"limit,omitempty" maximum:"100"`
+	Owner  *ownerFilter `json:"owner,omitempty"`
+	Tags   []int        `json:"tags,omitempty"`
+}
+
+func TestDeepObjectGin(t *testing.T) {
+	testTable := []struct {
+		description      string
+		query            string
+		expectedStatus   int
+		expectedResponse map[string]interface{}
+	}{
+		{
+			description:      "Bracketed keys",
+			query:            "filter[status]=active&filter[limit]=10&filter[owner][id]=42",
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+		{
+			description:      "Dotted keys",
+			query:            "filter.status=active&filter.owner.id=42",
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+		{
+			description:    "Invalid enum value",
+			query:          "filter[status]=deleted",
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"filter.status": "Must be one of the following: \"active\", \"archived\"",
+			},
+		},
+		{
+			description:    "Leaf values coerced by type",
+			query:          "filter[limit]=1000&filter[owner][id]=abc",
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"filter.limit":    "Must be less than or equal to 100",
+				"filter.owner.id": "Invalid type. Expected: integer, given: string",
+			},
+		},
+		{
+			description:      "Trailing brackets collect an array",
+			query:            "filter[tags][]=1&filter[tags][]=2",
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+		{
+			description:    "Array elements coerced by type",
+			query:          "filter[tags][]=1&filter[tags][]=abc",
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"filter.tags.1": "Invalid type. Expected: integer, given: string",
+			},
+		},
+	}
+
+	api := swag.New(swag.Endpoints(endpoint.New("GET", "/validate-test", "Test deep object params",
+		endpoint.Handler(func(*gin.Context) {}),
+		sv.DeepObject("filter", listFilter{}, "", false),
+	)))
+
+	r := createEngineGin(api)
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest("GET", "/validate-test?"+tt.query, nil)
+			if err != nil {
+				log.Fatalf("Error preparing request: %s", err)
+			}
+
+			r.ServeHTTP(w, req)
+
+			var body map[string]interface{}
+
+			if w.Body != nil && w.Body.String() != "" {
+				err := json.Unmarshal(w.Body.Bytes(), &body)
+				if err != nil {
+					panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+				}
+
+				assert.Equal(t, tt.expectedResponse, body["details"])
+			}
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestFormGin(t *testing.T) {
+	testTable := []struct {
+		description      string
+		url              string
+		form             url.Values
+		multipart        bool
+		expectedStatus   int
+		expectedResponse map[string]interface{}
+	}{
+		{
+			description:      "Form fields coerced to their declared types",
+			url:              "/form-test",
+			form:             url.Values{"count": {"10"}, "active": {"true"}, "ratio": {"0.5"}},
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+		{
+			description:      "Multipart fields coerced to their declared types",
+			url:              "/form-test",
+			form:             url.Values{"count": {"10"}, "active": {"false"}},
+			multipart:        true,
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+		{
+			description:    "Non-int value in an int form field",
+			url:            "/form-test",
+			form:           url.Values{"count": {"abc"}},
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"count": "Invalid type. Expected: integer, given: string",
+			},
+		},
+		{
+			description:    "Repeated values of a scalar form field",
+			url:            "/form-test",
+			form:           url.Values{"count": {"10", "abc"}},
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"count": "Invalid type. Expected: integer, given: array",
+			},
+		},
+		{
+			description:    "All values of an array form field validated",
+			url:            "/form-test",
+			form:           url.Values{"count": {"10"}, "ids": {"1", "abc"}},
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"ids.1": "Invalid type. Expected: integer, given: string",
+			},
+		},
+		{
+			description:    "Form body coerced to the body definition types",
+			url:            "/body-test",
+			form:           url.Values{"minimum": {"3"}, "maximum": {"1"}},
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"minimum": "Must be greater than or equal to 5",
+			},
+		},
+	}
+
+	api := swag.New(swag.Endpoints(
+		endpoint.New("POST", "/form-test", "Test form params",
+			endpoint.Handler(func(*gin.Context) {}),
+			endpoint.FormDataMap(map[string]swagger.Parameter{
+				"count":  {Type: "integer"},
+				"active": {Type: "boolean"},
+				"ratio":  {Type: "number"},
+				"ids":    {Type: "array", Items: &swagger.Items{Type: "integer"}},
+			}),
+		),
+		endpoint.New("POST", "/body-test", "Test form body",
+			endpoint.Handler(func(*gin.Context) {}),
+			endpoint.Body(payload{}, "Validation body", true),
+		),
+	))
+
+	r := createEngineGin(api)
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+
+			w := httptest.NewRecorder()
+
+			var req *http.Request
+			if tt.multipart {
+				fields := map[string]string{}
+				for k := range tt.form {
+					fields[k] = tt.form.Get(k)
+				}
+				req = prepareMultipartRequest(tt.url, fields, nil)
+			} else {
+				var err error
+				req, err = http.NewRequest("POST", tt.url, strings.NewReader(tt.form.Encode()))
+				if err != nil {
+					log.Fatalf("Error preparing request: %s", err)
+				}
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+
+			r.ServeHTTP(w, req)
+
+			var body map[string]interface{}
+
+			if w.Body != nil && w.Body.String() != "" {
+				err := json.Unmarshal(w.Body.Bytes(), &body)
+				if err != nil {
+					panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+				}
+
+				assert.Equal(t, tt.expectedResponse, body["details"])
+			}
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package swagvalidator
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/miketonks/swag/swagger"
+)
+
+// SwaggerValidatorHTTP net/http middleware
+func SwaggerValidatorHTTP(api *swagger.API, opts ...Option) func(http.Handler) http.Handler {
+	v := NewValidator(api, opts...)
+
+	return func(next http.Handler) http.Handler {
+		return v.Handler(next)
+	}
+}
+
+// Handler wraps next, validating every request that matches an endpoint of the API.
+// Requests that do not match any endpoint are passed through untouched.
+func (v *Validator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rt, params := v.match(r.Method, r.URL.Path)
+		if rt == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if status, body, failed := v.check(rt, r, params); failed {
+			writeJSON(w, status, body)
+		} else {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// writeJSON writes body as a json response with the given status
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,442 @@
+package swagvalidator_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	swag "github.com/miketonks/swag"
+	"github.com/miketonks/swag/endpoint"
+	"github.com/miketonks/swag/swagger"
+	"github.com/stretchr/testify/assert"
+
+	sv "github.com/Rekfuki/swag-validator"
+)
+
+func createHandlerHTTP(api *swagger.API) http.Handler {
+	return sv.SwaggerValidatorHTTP(api)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+}
+
+func TestRequestHTTP(t *testing.T) {
+	testTable := []struct {
+		description      string
+		method           string
+		url              string
+		expectedStatus   int
+		expectedResponse map[string]interface{}
+	}{
+		{
+			description:    "Non-int value in an int query param",
+			method:         "GET",
+			url:            "/api/pets?limit=abc",
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"limit": "Invalid type. Expected: integer, given: string",
+			},
+		},
+		{
+			description:      "Int value in an int query param",
+			method:           "GET",
+			url:              "/api/pets?limit=10",
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+		{
+			description:    "Non-uuid path param",
+			method:         "GET",
+			url:            "/api/pets/10",
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"pet_id": "Field does not match format 'uuid'",
+			},
+		},
+		{
+			description:      "Uuid path param",
+			method:           "GET",
+			url:              "/api/pets/" + testUUID,
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+		{
+			description:      "Path outside the base path is not validated",
+			method:           "GET",
+			url:              "/pets/10",
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+		{
+			description:      "Undeclared method is not validated",
+			method:           "DELETE",
+			url:              "/api/pets/10",
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+	}
+
+	api := swag.New(
+		swag.BasePath("/api"),
+		swag.Endpoints(
+			endpoint.New("GET", "/pets", "List pets",
+				endpoint.Query("limit", "integer", "", "", false),
+			),
+			endpoint.New("GET", "/pets/{pet_id}", "Get a pet",
+				endpoint.Path("pet_id", "string", "uuid", ""),
+			),
+		),
+	)
+
+	h := createHandlerHTTP(api)
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest(tt.method, tt.url, nil)
+			if err != nil {
+				log.Fatalf("Error preparing request: %s", err)
+			}
+
+			h.ServeHTTP(w, req)
+
+			var body map[string]interface{}
+
+			if w.Body != nil && w.Body.String() != "" {
+				err := json.Unmarshal(w.Body.Bytes(), &body)
+				if err != nil {
+					panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+				}
+
+				assert.Equal(t, tt.expectedResponse, body["details"])
+			}
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestPayloadHTTP(t *testing.T) {
+	api := swag.New(swag.Endpoints(endpoint.New("POST", "/validate-test", "Test the validator",
+		endpoint.Body(payload{}, "Validation body", true),
+	)))
+
+	h := createHandlerHTTP(api)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, preparePostRequest("/validate-test", payload{FormatString: "not-a-uuid"}))
+
+	var body map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &body)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to unmarshal body. Error: %s", err))
+	}
+
+	assert.Equal(t, 400, w.Code)
+	assert.Equal(t, map[string]interface{}{"format_str": "Field does not match format 'uuid'"}, body["details"])
+}
+
+func TestChunkedBodyHTTP(t *testing.T) {
+	testTable := []struct {
+		description      string
+		body             string
+		expectedStatus   int
+		expectedResponse map[string]interface{}
+	}{
+		{
+			description:    "Invalid chunked body",
+			body:           `{"format_str":"not-a-uuid"}`,
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"format_str": "Field does not match format 'uuid'",
+			},
+		},
+		{
+			description:      "Valid chunked body",
+			body:             `{"format_str":"` + testUUID + `"}`,
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+		{
+			description:    "Empty chunked body",
+			body:           "",
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"body": "body is required",
+			},
+		},
+	}
+
+	api := swag.New(swag.Endpoints(endpoint.New("POST", "/validate-test", "Test the validator",
+		endpoint.Body(payload{}, "Validation body", true),
+	)))
+
+	var transferEncoding []string
+	srv := httptest.NewServer(sv.SwaggerValidatorHTTP(api)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		transferEncoding = r.TransferEncoding
+	})))
+	defer srv.Close()
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+			transferEncoding = nil
+
+			// a reader of unknown length is sent chunked
+			req, err := http.NewRequest("POST", srv.URL+"/validate-test", ioutil.NopCloser(strings.NewReader(tt.body)))
+			if err != nil {
+				log.Fatalf("Error preparing request: %s", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				log.Fatalf("Error sending request: %s", err)
+			}
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.expectedResponse != nil {
+				var body map[string]interface{}
+				if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+					panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+				}
+				assert.Equal(t, tt.expectedResponse, body["details"])
+			} else {
+				assert.Equal(t, []string{"chunked"}, transferEncoding)
+			}
+		})
+	}
+}
+
+func TestBodyErrorsHTTP(t *testing.T) {
+	testTable := []struct {
+		description      string
+		contentType      string
+		body             string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			description:      "Empty body",
+			contentType:      "application/json",
+			body:             " \n",
+			expectedStatus:   400,
+			expectedResponse: `{"code":"empty_body","details":{"body":"body is required"},"message":"Validation error"}`,
+		},
+		{
+			description:      "Syntax error",
+			contentType:      "application/json",
+			body:             `{"format_str": x}`,
+			expectedStatus:   400,
+			expectedResponse: `{"code":"syntax_error","details":{"body":"Invalid JSON at line 1, column 16 (offset 15): invalid character 'x' looking for beginning of value"},"message":"Validation error"}`,
+		},
+		{
+			description:      "Syntax error on a later line",
+			contentType:      "application/json",
+			body:             "{\n  \"format_str\": \"abc\",\n  \"minimum\": 1O\n}",
+			expectedStatus:   400,
+			expectedResponse: `{"code":"syntax_error","details":{"body":"Invalid JSON at line 3, column 15 (offset 39): invalid character 'O' after object key:value pair"},"message":"Validation error"}`,
+		},
+		{
+			description:      "Truncated body",
+			contentType:      "application/json",
+			body:             `{"format_str":`,
+			expectedStatus:   400,
+			expectedResponse: `{"code":"syntax_error","details":{"body":"Invalid JSON at line 1, column 15 (offset 14): unexpected end of JSON input"},"message":"Validation error"}`,
+		},
+		{
+			description:      "Trailing data",
+			contentType:      "application/json",
+			body:             `{}  {}`,
+			expectedStatus:   400,
+			expectedResponse: `{"code":"trailing_data","details":{"body":"Unexpected data after the JSON value at line 1, column 5 (offset 4)"},"message":"Validation error"}`,
+		},
+		{
+			description:      "Trailing data on a later line",
+			contentType:      "application/json",
+			body:             "{\"format_str\": \"a\"}\n]",
+			expectedStatus:   400,
+			expectedResponse: `{"code":"trailing_data","details":{"body":"Unexpected data after the JSON value at line 2, column 1 (offset 20)"},"message":"Validation error"}`,
+		},
+		{
+			description:      "Wrong top-level type",
+			contentType:      "application/json",
+			body:             `[{}]`,
+			expectedStatus:   400,
+			expectedResponse: `{"code":"invalid_body_type","details":{"body":"Invalid type. Expected: object, given: array"},"message":"Validation error"}`,
+		},
+		{
+			description:      "Unsupported content type",
+			contentType:      "text/plain",
+			body:             `{}`,
+			expectedStatus:   415,
+			expectedResponse: `{"code":"unsupported_content_type","details":{"body":"Unsupported content type text/plain"},"message":"Validation error"}`,
+		},
+	}
+
+	api := swag.New(swag.Endpoints(endpoint.New("POST", "/validate-test", "Test the validator",
+		endpoint.Body(payload{}, "Validation body", true),
+	)))
+
+	h := createHandlerHTTP(api)
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/validate-test", strings.NewReader(tt.body))
+			if err != nil {
+				log.Fatalf("Error preparing request: %s", err)
+			}
+			req.Header.Set("Content-Type", tt.contentType)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedResponse, w.Body.String())
+		})
+	}
+}
+
+type counter struct {
+	ID     int64   `json:"id,omitempty" maximum:"9007199254740992"`
+	Count  int32   `json:"count,omitempty"`
+	Size   int     `json:"size,omitempty"`
+	Unique uint32  `json:"unique,omitempty"`
+	Levels []int8  `json:"levels,omitempty"`
+	Parent *parent `json:"parent,omitempty"`
+}
+
+type parent struct {
+	ID uint64 `json:"id,omitempty"`
+}
+
+func TestIntegerPrecisionHTTP(t *testing.T) {
+	testTable := []struct {
+		description      string
+		body             string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			description:    "Largest exact float64 integer",
+			body:           `{"id": 9007199254740992, "count": 2147483647}`,
+			expectedStatus: 200,
+		},
+		{
+			description:    "int and uint32 fields declared int32",
+			body:           `{"size": 3000000000, "unique": 4000000000}`,
+			expectedStatus: 200,
+		},
+		{
+			description:    "uint64 field declared int64",
+			body:           `{"parent": {"id": 18446744073709551615}}`,
+			expectedStatus: 200,
+		},
+		{
+			description:      "Maximum exceeded above 2^53",
+			body:             `{"id": 9007199254740993}`,
+			expectedStatus:   400,
+			expectedResponse: `{"details":{"id":"Must be less than or equal to 9007199254740992"},"message":"Validation error"}`,
+		},
+		{
+			description:      "int64 overflow",
+			body:             `{"id": -9223372036854775809}`,
+			expectedStatus:   400,
+			expectedResponse: `{"details":{"id":"Must be greater than or equal to -9223372036854775808"},"message":"Validation error"}`,
+		},
+		{
+			description:      "int32 overflow",
+			body:             `{"count": 2147483648}`,
+			expectedStatus:   400,
+			expectedResponse: `{"details":{"count":"Must be less than or equal to 2147483647"},"message":"Validation error"}`,
+		},
+		{
+			description:      "uint32 overflow",
+			body:             `{"unique": 4294967296}`,
+			expectedStatus:   400,
+			expectedResponse: `{"details":{"unique":"Must be less than or equal to 4294967295"},"message":"Validation error"}`,
+		},
+		{
+			description:      "Negative unsigned integer",
+			body:             `{"parent": {"id": -1}}`,
+			expectedStatus:   400,
+			expectedResponse: `{"details":{"parent.id":"Must be greater than or equal to 0"},"message":"Validation error"}`,
+		},
+		{
+			description:      "int8 array item overflow",
+			body:             `{"levels": [1, 128]}`,
+			expectedStatus:   400,
+			expectedResponse: `{"details":{"levels.1":"Must be less than or equal to 127"},"message":"Validation error"}`,
+		},
+		{
+			description:      "Array body",
+			body:             `[{"id": 1}, {"count": 2147483648, "size": "a"}]`,
+			expectedStatus:   400,
+			expectedResponse: `{"details":{"1.count":"Must be less than or equal to 2147483647","1.size":"Invalid type. Expected: integer, given: string"},"message":"Validation error"}`,
+		},
+		{
+			description:      "Fraction of a large integer",
+			body:             `{"id": 9007199254740991.5}`,
+			expectedStatus:   400,
+			expectedResponse: `{"details":{"id":"Invalid type. Expected: integer, given: number"},"message":"Validation error"}`,
+		},
+	}
+
+	api := swag.New(swag.Endpoints(
+		endpoint.New("POST", "/validate-test", "Test the validator",
+			endpoint.Body(counter{}, "Validation body", true),
+		),
+		endpoint.New("POST", "/validate-test/batch", "Test the validator",
+			endpoint.Body([]counter{}, "Validation body", true),
+		),
+	))
+
+	h := createHandlerHTTP(api)
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+			url := "/validate-test"
+			if strings.HasPrefix(tt.body, "[") {
+				url += "/batch"
+			}
+			req, err := http.NewRequest("POST", url, strings.NewReader(tt.body))
+			if err != nil {
+				log.Fatalf("Error preparing request: %s", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedResponse != "" {
+				assert.JSONEq(t, tt.expectedResponse, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSharedNamesHTTP(t *testing.T) {
+	api := swag.New(swag.Endpoints(endpoint.New("PUT", "/counters/{id}", "Replace a counter",
+		endpoint.Path("id", "integer", "", ""),
+		endpoint.Body(counter{}, "Counter", true),
+	)))
+
+	h := createHandlerHTTP(api)
+
+	req, err := http.NewRequest("PUT", "/counters/abc", strings.NewReader(`{"id": 1.5, "count": "a"}`))
+	if err != nil {
+		log.Fatalf("Error preparing request: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	assert.JSONEq(t, `{"details":{"path.id":"Invalid type. Expected: integer, given: string","body.id":"Invalid type. Expected: integer, given: number","count":"Invalid type. Expected: integer, given: string"},"message":"Validation error"}`, w.Body.String())
+}
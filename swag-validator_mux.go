@@ -0,0 +1,40 @@
+package swagvalidator
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/miketonks/swag/swagger"
+)
+
+// SwaggerValidatorMux gorilla/mux middleware
+func SwaggerValidatorMux(api *swagger.API, opts ...Option) mux.MiddlewareFunc {
+	v := NewValidator(api, opts...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			current := mux.CurrentRoute(r)
+			if current == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			template, err := current.GetPathTemplate()
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rt, found := v.routes[r.Method+templateColonPath(template)]
+			if !found {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if status, body, failed := v.check(rt, r, mux.Vars(r)); failed {
+				writeJSON(w, status, body)
+			} else {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package swagvalidator_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	swag "github.com/miketonks/swag"
+	"github.com/miketonks/swag/endpoint"
+	"github.com/miketonks/swag/swagger"
+	"github.com/stretchr/testify/assert"
+
+	sv "github.com/Rekfuki/swag-validator"
+)
+
+func createRouterMux(api *swagger.API) (r *mux.Router) {
+	r = mux.NewRouter()
+	r.Use(sv.SwaggerValidatorMux(api))
+	api.Walk(func(path string, endpoint *swagger.Endpoint) {
+		if endpoint.Handler == nil {
+			return
+		}
+		r.Handle(path, endpoint.Handler.(http.Handler)).Methods(endpoint.Method)
+	})
+	return
+}
+
+func TestRequestMux(t *testing.T) {
+	testTable := []struct {
+		description      string
+		url              string
+		expectedStatus   int
+		expectedResponse map[string]interface{}
+	}{
+		{
+			description:    "Non-int value in an int query param",
+			url:            "/api/pets?limit=abc",
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"limit": "Invalid type. Expected: integer, given: string",
+			},
+		},
+		{
+			description:      "Int value in an int query param",
+			url:              "/api/pets?limit=10",
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+		{
+			description:    "Non-uuid path param",
+			url:            "/api/pets/10",
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"pet_id": "Field does not match format 'uuid'",
+			},
+		},
+		{
+			description:      "Uuid path param",
+			url:              "/api/pets/" + testUUID,
+			expectedStatus:   200,
+			expectedResponse: nil,
+		},
+		{
+			description:    "Out of range path param on a route with a regexp pattern",
+			url:            "/api/owners/10",
+			expectedStatus: 400,
+			expectedResponse: map[string]interface{}{
+				"owner_id": "Must be less than or equal to 5",
+			},
+		},
+		{
+			description:      "Unregistered route is not validated",
+			url:              "/api/other/10",
+			expectedStatus:   404,
+			expectedResponse: nil,
+		},
+	}
+
+	maximum := int64(5)
+	api := swag.New(
+		swag.BasePath("/api"),
+		swag.Endpoints(
+			endpoint.New("GET", "/pets", "List pets",
+				endpoint.Handler(httpHandler),
+				endpoint.Query("limit", "integer", "", "", false),
+			),
+			endpoint.New("GET", "/pets/{pet_id}", "Get a pet",
+				endpoint.Handler(httpHandler),
+				endpoint.Path("pet_id", "string", "uuid", ""),
+			),
+			endpoint.New("GET", "/owners/{owner_id}", "Get an owner",
+				endpoint.PathMap(map[string]swagger.Parameter{
+					"owner_id": {Type: "integer", Maximum: &maximum},
+				}),
+			),
+		),
+	)
+
+	r := createRouterMux(api)
+	r.HandleFunc("/api/owners/{owner_id:[0-9]+}", httpHandler).Methods("GET")
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest("GET", tt.url, nil)
+			if err != nil {
+				log.Fatalf("Error preparing request: %s", err)
+			}
+
+			r.ServeHTTP(w, req)
+
+			var body map[string]interface{}
+
+			if w.Body != nil && w.Body.String() != "" && tt.expectedStatus != 404 {
+				err := json.Unmarshal(w.Body.Bytes(), &body)
+				if err != nil {
+					panic(fmt.Sprintf("Failed to unmarshal body while running test: %q. Error: %s", tt.description, err))
+				}
+
+				assert.Equal(t, tt.expectedResponse, body["details"])
+			}
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
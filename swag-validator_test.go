@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"log"
+	"mime/multipart"
 	"net/http"
 )
 
@@ -53,3 +54,29 @@ func preparePostRequest(url string, body payload) *http.Request {
 
 	return req
 }
+
+func prepareMultipartRequest(url string, fields map[string]string, files map[string]string) *http.Request {
+	buff := &bytes.Buffer{}
+	mw := multipart.NewWriter(buff)
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			log.Fatalf("Failed to write field: %s", err)
+		}
+	}
+	for k, v := range files {
+		fw, err := mw.CreateFormFile(k, k+".txt")
+		if err != nil {
+			log.Fatalf("Failed to create file: %s", err)
+		}
+		fw.Write([]byte(v))
+	}
+	mw.Close()
+
+	req, err := http.NewRequest("POST", url, buff)
+	if err != nil {
+		log.Fatalf("Error preparing request: %s", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	return req
+}
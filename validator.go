@@ -0,0 +1,916 @@
+package swagvalidator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/miketonks/swag"
+	"github.com/miketonks/swag/swagger"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// definitionsURL identifies the document holding the definitions shared by all endpoint schemas
+const definitionsURL = "swag-validator://definitions"
+
+// endpointsURL prefixes the documents holding the endpoint schemas
+const endpointsURL = "swag-validator://endpoints"
+
+var reTemplateParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?}`)
+
+// ErrRouteNotFound is returned by ValidateRequest when the request does not
+// match any endpoint of the API
+var ErrRouteNotFound = errors.New("swagger route not found")
+
+// Validator validates http requests against the endpoints of a swagger API.
+// It is framework agnostic, the Gin and Echo middlewares are thin adapters over it.
+type Validator struct {
+	basePath         string
+	routes           map[string]*route
+	handlers         map[string]*route
+	byMethod         map[string][]*route
+	matchHandlerName bool
+	locale           ErrorLocale
+	templates        map[string]*template.Template
+	render           ErrorRenderer
+	skip             func(r *http.Request) bool
+	multipartMemory  int64
+	streaming        bool
+	maxBodySize      int64
+	bodySizes        map[string]int64
+	unknown          map[string]UnknownPolicy
+	knownHeaders     map[string]bool
+	formats          map[string]map[string]string
+	files            map[string]map[string]FileConstraints
+	invalidOptions   []string
+	definitions      map[string]interface{}
+	integerKinds     map[string]map[string]reflect.Kind
+	hooks            []Hook
+}
+
+// Result holds the outcome of validating a single request
+type Result struct {
+	Errors map[string]string
+	// Status is the response status suggested for the failure, zero for the default 400
+	Status int
+	// Code identifies the failure of a request body that could not be validated e.g. CodeSyntaxError
+	Code string
+}
+
+// Valid reports whether the request passed validation
+func (r *Result) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// route is a single swagger endpoint prepared for validation
+type route struct {
+	endpoint  *swagger.Endpoint
+	method    string
+	path      string
+	segments  []string
+	schema    *gojsonschema.Schema
+	schemaErr error
+	// params holds the schema properties of the declared parameters by location e.g. query
+	params map[string]map[string]interface{}
+	// body is the schema of the body parameter, if declared
+	body map[string]interface{}
+	// shared holds the names declared in more than one location, whose errors keep their location e.g. path.id
+	shared map[string]bool
+	// formats holds the collectionFormat of the array parameters by location and name e.g. query.tags
+	formats map[string]string
+	// files holds the constraints of the file parameters by name
+	files map[string]FileConstraints
+	// maxBodySize is the maximum size of the request body, zero or less for no limit
+	maxBodySize int64
+}
+
+// NewValidator builds a Validator for every endpoint of the api
+func NewValidator(api *swagger.API, opts ...Option) *Validator {
+	v := &Validator{
+		basePath:        strings.TrimRight(api.BasePath, "/"),
+		routes:          map[string]*route{},
+		handlers:        map[string]*route{},
+		byMethod:        map[string][]*route{},
+		locale:          CustomLocale{},
+		render:          renderErrors,
+		multipartMemory: MaxMemory,
+		maxBodySize:     DefaultMaxBodySize,
+		bodySizes:       map[string]int64{},
+		unknown:         map[string]UnknownPolicy{},
+		knownHeaders:    map[string]bool{},
+		formats:         map[string]map[string]string{},
+		files:           map[string]map[string]FileConstraints{},
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	// gojsonschema.Locale is a package global, so errors are formatted with the Validator locale instead
+	v.templates = errorTemplates(v.locale)
+
+	// definitions are compiled once and referenced from every endpoint schema
+	loader := gojsonschema.NewSchemaLoader()
+	definitionsLoader := gojsonschema.NewGoLoader(map[string]interface{}{
+		"definitions": buildSchemaDefinitions(api),
+	})
+	definitionsErr := loader.AddSchema(definitionsURL, definitionsLoader)
+	// deep object query parameters are coerced by the types of their definitions
+	if definitions, err := definitionsLoader.LoadJSON(); err == nil {
+		v.definitions, _ = definitions.(map[string]interface{})["definitions"].(map[string]interface{})
+	}
+	v.integerKinds = buildIntegerKinds(api)
+
+	endpoints := map[string]bool{}
+	for _, p := range api.Paths {
+		for _, e := range []*swagger.Endpoint{
+			p.Delete,
+			p.Get,
+			p.Post,
+			p.Put,
+			p.Patch,
+			p.Head,
+			p.Options,
+			p.Trace,
+			p.Connect} {
+			if e == nil {
+				continue
+			}
+
+			schemaLoader := gojsonschema.NewGoLoader(buildRequestSchema(e))
+
+			ref, _ := schemaLoader.LoadJSON()
+			properties, _ := ref.(map[string]interface{})["properties"].(map[string]interface{})
+			params := map[string]map[string]interface{}{}
+			var body map[string]interface{}
+			for in, group := range properties {
+				if in == "body" {
+					body, _ = group.(map[string]interface{})
+					continue
+				}
+				params[in], _ = group.(map[string]interface{})["properties"].(map[string]interface{})
+			}
+
+			// a schema that fails to compile is reported on every request to the endpoint
+			compiled, err := compileSchema(loader, fmt.Sprintf("%s/%d", endpointsURL, len(v.routes)), schemaLoader)
+			if definitionsErr != nil {
+				err = definitionsErr
+			}
+
+			key := e.Method + " " + swag.ColonPath(e.Path)
+			endpoints[key] = true
+
+			path := v.basePath + swag.ColonPath(e.Path)
+			rt := &route{
+				endpoint:  e,
+				method:    e.Method,
+				path:      path,
+				segments:  strings.Split(strings.Trim(path, "/"), "/"),
+				schema:    compiled,
+				schemaErr: err,
+				params:    params,
+				body:      body,
+				formats:   map[string]string{},
+				files:     v.files[key],
+			}
+			rt.shared = v.sharedNames(params, body)
+			v.checkOptions(rt, key)
+			rt.maxBodySize = v.maxBodySize
+			if n, found := v.bodySizes[key]; found {
+				rt.maxBodySize = n
+			}
+
+			v.routes[e.Method+path] = rt
+			v.byMethod[e.Method] = append(v.byMethod[e.Method], rt)
+			if e.Handler != nil {
+				v.handlers[nameOfFunction(e.Handler)] = rt
+			}
+		}
+	}
+
+	// the per-endpoint options are keyed as the endpoints are e.g. "GET /pets/:id"
+	undeclared := map[string]bool{}
+	for key := range v.formats {
+		undeclared[key] = !endpoints[key]
+	}
+	for key := range v.files {
+		undeclared[key] = !endpoints[key]
+	}
+	for key := range v.bodySizes {
+		undeclared[key] = !endpoints[key]
+	}
+	for key, isUndeclared := range undeclared {
+		if isUndeclared {
+			v.invalidOptions = append(v.invalidOptions, fmt.Sprintf("options are set for undeclared endpoint %s", key))
+		}
+	}
+
+	return v
+}
+
+// checkOptions keeps the collectionFormats set for the parameters of rt, the endpoint identified by key,
+// and lists the options set for it that are invalid or refer to undeclared parameters
+func (v *Validator) checkOptions(rt *route, key string) {
+	for k, format := range v.formats[key] {
+		in := strings.SplitN(k, ".", 2)
+		if _, declared := rt.params[in[0]][in[1]]; !declared {
+			v.invalidOptions = append(v.invalidOptions, fmt.Sprintf("collectionFormat is set for undeclared parameter %s of %s", k, rt))
+			continue
+		}
+		if _, found := collectionSeparators[format]; !found {
+			v.invalidOptions = append(v.invalidOptions, fmt.Sprintf("collectionFormat %q of parameter %s of %s is unknown", format, k, rt))
+			continue
+		}
+		rt.formats[k] = format
+	}
+	if v.streaming && rt.maxBodySize <= 0 && len(rt.params["formData"]) > 0 {
+		v.invalidOptions = append(v.invalidOptions, fmt.Sprintf("StreamMultipart is ignored for %s, which has no body size limit", rt))
+	}
+	for name := range v.files[key] {
+		if !isFileParam(rt, name) {
+			v.invalidOptions = append(v.invalidOptions, fmt.Sprintf("file constraints are set for undeclared file parameter %s of %s", name, rt))
+		}
+	}
+}
+
+// compileSchema adds schema to the loader under url, so that it can reference the shared definitions, and compiles it
+func compileSchema(loader *gojsonschema.SchemaLoader, url string, schema gojsonschema.JSONLoader) (*gojsonschema.Schema, error) {
+	if err := loader.AddSchema(url, schema); err != nil {
+		return nil, err
+	}
+	return loader.Compile(gojsonschema.NewReferenceLoader(url))
+}
+
+// String identifies the route as "METHOD /colon/path"
+func (rt *route) String() string {
+	return rt.method + " " + rt.path
+}
+
+// ValidateRequest validates r against the endpoint matching its method and path.
+// When pathParams is nil the path parameters are extracted from the request path.
+func (v *Validator) ValidateRequest(r *http.Request, pathParams map[string]string) (*Result, error) {
+	rt, params := v.match(r.Method, r.URL.Path)
+	if rt == nil {
+		return nil, ErrRouteNotFound
+	}
+	if pathParams != nil {
+		params = pathParams
+	}
+	return v.validate(rt, r, params)
+}
+
+// match finds the route for method and path, preferring static segments over parameters; ties go to
+// the route whose first static segment comes first
+func (v *Validator) match(method, path string) (*route, map[string]string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	var (
+		best       *route
+		bestParams map[string]string
+		bestStatic = -1
+	)
+	for _, rt := range v.byMethod[method] {
+		if len(rt.segments) != len(segments) {
+			continue
+		}
+
+		params := map[string]string{}
+		static := 0
+		for i, s := range rt.segments {
+			if strings.HasPrefix(s, ":") {
+				if segments[i] == "" {
+					static = -1
+					break
+				}
+				params[s[1:]] = segments[i]
+			} else if s == segments[i] {
+				static++
+			} else {
+				static = -1
+				break
+			}
+		}
+
+		if static > bestStatic || (static == bestStatic && static >= 0 && rt.precedes(best)) {
+			best, bestParams, bestStatic = rt, params, static
+		}
+	}
+
+	return best, bestParams
+}
+
+// precedes breaks the tie between two routes matching a path with as many static segments,
+// preferring the leftmost static segment as chi and mux do e.g. /a/:x over /:y/b for /a/b
+func (rt *route) precedes(other *route) bool {
+	for i, s := range rt.segments {
+		isParam, otherIsParam := strings.HasPrefix(s, ":"), strings.HasPrefix(other.segments[i], ":")
+		if isParam != otherIsParam {
+			return otherIsParam
+		}
+	}
+	// the routes only differ by the names of their parameters
+	return rt.path < other.path
+}
+
+// check validates r against rt, returning the status and body of the error response when it fails
+func (v *Validator) check(rt *route, r *http.Request, pathParams map[string]string) (int, interface{}, bool) {
+	if v.skip != nil && v.skip(r) {
+		return 0, nil, false
+	}
+
+	result, err := v.validate(rt, r, pathParams)
+	if err == nil && result.Valid() {
+		return 0, nil, false
+	}
+
+	status, body := v.render(r, result, err)
+	return status, body, true
+}
+
+// validate validates r against rt and passes the outcome to the hooks
+func (v *Validator) validate(rt *route, r *http.Request, pathParams map[string]string) (*Result, error) {
+	result, err := v.validateRequest(rt, r, pathParams)
+	for _, hook := range v.hooks {
+		hook(r, result, err)
+	}
+	return result, err
+}
+
+// validateRequest builds the request document and validates it against the route schema
+func (v *Validator) validateRequest(rt *route, r *http.Request, pathParams map[string]string) (*Result, error) {
+	if rt.schemaErr != nil {
+		return nil, rt.schemaErr
+	}
+
+	// every parameter is only looked up in the location it is declared in
+	document := map[string]interface{}{}
+	for in := range rt.params {
+		document[in] = map[string]interface{}{}
+	}
+	path := document["path"].(map[string]interface{})
+	query := document["query"].(map[string]interface{})
+	formData := document["formData"].(map[string]interface{})
+
+	for k, p := range pathParams {
+		path[k] = loadValueForKey(rt.params["path"], k, []string{p}, rt.formats["path."+k])
+	}
+	for k, q := range r.URL.Query() {
+		if _, declared := rt.params["query"][k]; declared {
+			query[k] = loadValueForKey(rt.params["query"], k, q, rt.formats["query."+k])
+			continue
+		}
+		// bracketed and dotted keys e.g. filter[status] are assembled into their object parameter
+		name, path := deepKey(k)
+		prop, declared := rt.params["query"][name].(map[string]interface{})
+		if len(path) == 0 || !declared {
+			continue
+		}
+		obj, isObject := query[name].(map[string]interface{})
+		if !isObject {
+			if _, set := query[name]; set {
+				continue
+			}
+			obj = map[string]interface{}{}
+			query[name] = obj
+		}
+		v.setDeep(obj, prop, path, q)
+	}
+	if headers, found := rt.params["header"]; found {
+		header := document["header"].(map[string]interface{})
+		for k := range headers {
+			if h, found := r.Header[http.CanonicalHeaderKey(k)]; found {
+				header[k] = loadValueForKey(headers, k, h, rt.formats["header."+k])
+			}
+		}
+	}
+	if cookies, found := rt.params["cookie"]; found {
+		values := map[string][]string{}
+		for _, c := range r.Cookies() {
+			if _, found := cookies[c.Name]; found {
+				values[c.Name] = append(values[c.Name], c.Value)
+			}
+		}
+		cookie := document["cookie"].(map[string]interface{})
+		for k, c := range values {
+			cookie[k] = loadValueForKey(cookies, k, c, "")
+		}
+	}
+
+	// the body size is enforced while the body is read, a body declared too large is not read at all
+	var limited *limitedBody
+	if rt.maxBodySize > 0 && r.Body != nil && r.Body != http.NoBody {
+		if r.ContentLength > rt.maxBodySize {
+			return bodyTooLarge(rt.maxBodySize), nil
+		}
+		limited = &limitedBody{ReadCloser: r.Body, remaining: rt.maxBodySize}
+		r.Body = limited
+	}
+
+	// For muiltipart form, handle params and file uploads; any other media type
+	// e.g. application/json; charset=utf-8 or application/vnd.api+json is parsed as json
+	fileErrors := map[string]string{}
+	integerErrors := map[string]string{}
+	switch mediaType := contentType(r); mediaType {
+	case "multipart/form-data":
+		// the parts are kept in memory, so they are only streamed when the body size is limited
+		if v.streaming && rt.maxBodySize > 0 {
+			if result := v.readMultipart(rt, r, formData); result != nil {
+				if limited.Exceeded() {
+					return bodyTooLarge(rt.maxBodySize), nil
+				}
+				return result, nil
+			}
+			break
+		}
+		if result := formError(r, r.ParseMultipartForm(v.multipartMemory), limited, rt.maxBodySize); result != nil {
+			return result, nil
+		}
+
+		for k, f := range r.PostForm {
+			if _, declared := rt.params["formData"][k]; declared {
+				v.setFormValue(rt, formData, fileErrors, k, f)
+			}
+		}
+		if r.MultipartForm != nil && r.MultipartForm.File != nil {
+			for k, files := range r.MultipartForm.File {
+				if _, declared := rt.params["formData"][k]; declared {
+					formData[k] = "x"
+				}
+				if c, found := rt.files[k]; found {
+					for field, description := range c.check(k, files) {
+						fileErrors[field] = description
+					}
+				}
+			}
+		}
+	case "application/x-www-form-urlencoded":
+		if result := formError(r, r.ParseForm(), limited, rt.maxBodySize); result != nil {
+			return result, nil
+		}
+
+		// an endpoint that only declares a body receives the form as its body
+		if len(rt.params["formData"]) == 0 && hasBody(rt.endpoint) {
+			body := map[string]interface{}{}
+			for k, f := range r.PostForm {
+				body[k] = loadValueForKey(map[string]interface{}{k: v.property(rt.body, k)}, k, f, "")
+			}
+			document["body"] = body
+			break
+		}
+		for k, f := range r.PostForm {
+			if _, declared := rt.params["formData"][k]; declared {
+				v.setFormValue(rt, formData, fileErrors, k, f)
+			}
+		}
+	default:
+		// the length of a chunked body is unknown (-1), so the body is read whenever there is one
+		var b []byte
+		if r.Body != nil && r.Body != http.NoBody {
+			var err error
+			b, err = ioutil.ReadAll(r.Body)
+			if limited.Exceeded() {
+				return bodyTooLarge(rt.maxBodySize), nil
+			}
+			if err != nil {
+				return bodyError(CodeUnreadableBody, "Failed to read request body"), nil
+			}
+
+			// reset the request body to the original unread state
+			r.Body = ioutil.NopCloser(bytes.NewBuffer(b))
+		}
+
+		if len(bytes.TrimSpace(b)) == 0 {
+			if requiresBody(rt.endpoint) {
+				return bodyError(CodeEmptyBody, v.describeRequired("body")), nil
+			}
+			break
+		}
+		// json is the only other media type a body can be validated in
+		if !isJSON(mediaType) {
+			return unsupportedContentType(mediaType), nil
+		}
+		body, result := v.decodeJSON(rt, b)
+		if result != nil {
+			return result, nil
+		}
+		document["body"] = body
+		if rt.body != nil {
+			v.checkIntegers(rt.body, body, "", integerErrors)
+		}
+	}
+
+	if limited.Exceeded() {
+		return bodyTooLarge(rt.maxBodySize), nil
+	}
+
+	unknown := v.unknownParams(rt, r, document["body"] == nil)
+
+	documentLoader := gojsonschema.NewGoLoader(document)
+	result, err := rt.schema.Validate(documentLoader)
+	if err != nil {
+		return nil, err
+	}
+
+	errors := v.resultErrors(rt, result)
+	// an integer out of the range of its Go kind is only reported when its schema holds
+	for k, description := range integerErrors {
+		if _, found := errors[k]; !found {
+			errors[k] = description
+		}
+	}
+	for _, extra := range []map[string]string{unknown, fileErrors} {
+		for k, description := range extra {
+			errors[k] = description
+		}
+	}
+	return &Result{Errors: errors}, nil
+}
+
+// unknownParams applies the unknown parameter policies to the parameters of r not declared by rt.
+// It returns the errors of the rejected parameters, stripped parameters are removed from r.
+func (v *Validator) unknownParams(rt *route, r *http.Request, form bool) map[string]string {
+	errors := map[string]string{}
+	for in, policy := range v.unknown {
+		if policy == IgnoreUnknown || (in == "formData" && !form) {
+			continue
+		}
+
+		var names []string
+		switch in {
+		case "query":
+			for k := range r.URL.Query() {
+				if name, path := deepKey(k); len(path) > 0 && rt.params[in][name] != nil {
+					k = name
+				}
+				names = append(names, k)
+			}
+		case "header":
+			for k := range r.Header {
+				if !standardHeaders[k] && !v.knownHeaders[k] && !strings.HasPrefix(k, "Sec-") {
+					names = append(names, k)
+				}
+			}
+		case "formData":
+			for k := range r.PostForm {
+				names = append(names, k)
+			}
+			if r.MultipartForm != nil {
+				for k := range r.MultipartForm.File {
+					names = append(names, k)
+				}
+			}
+		}
+
+		for _, k := range undeclared(rt.params[in], names, in == "header") {
+			if policy == RejectUnknown {
+				if in == "header" {
+					errors["header."+k] = "Unknown header parameter"
+				} else {
+					errors[k] = fmt.Sprintf("Unknown %s parameter", unknownLabels[in])
+				}
+				continue
+			}
+
+			switch in {
+			case "query":
+				query := r.URL.Query()
+				query.Del(k)
+				r.URL.RawQuery = query.Encode()
+			case "header":
+				r.Header.Del(k)
+			case "formData":
+				delete(r.Form, k)
+				delete(r.PostForm, k)
+				if r.MultipartForm != nil {
+					delete(r.MultipartForm.Value, k)
+					delete(r.MultipartForm.File, k)
+				}
+			}
+		}
+	}
+	return errors
+}
+
+// standardHeaders are the headers set by clients, proxies and the transport rather than declared by
+// endpoints, they are never unknown; neither are the Sec- headers of browsers, see KnownHeaders
+var standardHeaders = map[string]bool{
+	"Accept":                         true,
+	"Accept-Charset":                 true,
+	"Accept-Encoding":                true,
+	"Accept-Language":                true,
+	"Access-Control-Request-Headers": true,
+	"Access-Control-Request-Method":  true,
+	"Authorization":                  true,
+	"Cache-Control":                  true,
+	"Connection":                     true,
+	"Content-Encoding":               true,
+	"Content-Length":                 true,
+	"Content-Type":                   true,
+	"Cookie":                         true,
+	"Date":                           true,
+	"Dnt":                            true,
+	"Expect":                         true,
+	"Forwarded":                      true,
+	"From":                           true,
+	"Host":                           true,
+	"If-Match":                       true,
+	"If-Modified-Since":              true,
+	"If-None-Match":                  true,
+	"If-Range":                       true,
+	"If-Unmodified-Since":            true,
+	"Keep-Alive":                     true,
+	"Max-Forwards":                   true,
+	"Origin":                         true,
+	"Pragma":                         true,
+	"Proxy-Authorization":            true,
+	"Proxy-Connection":               true,
+	"Range":                          true,
+	"Referer":                        true,
+	"Te":                             true,
+	"Trailer":                        true,
+	"Transfer-Encoding":              true,
+	"Upgrade":                        true,
+	"Upgrade-Insecure-Requests":      true,
+	"User-Agent":                     true,
+	"Via":                            true,
+	"Warning":                        true,
+	"X-Forwarded-For":                true,
+	"X-Forwarded-Host":               true,
+	"X-Forwarded-Proto":              true,
+	"X-Real-Ip":                      true,
+	"X-Requested-With":               true,
+}
+
+// unknownLabels names the locations in the errors of rejected parameters
+var unknownLabels = map[string]string{
+	"query":    "query",
+	"formData": "form",
+}
+
+// undeclared returns the names not declared in params, header names are compared case-insensitively
+func undeclared(params map[string]interface{}, names []string, header bool) []string {
+	declared := map[string]bool{}
+	for k := range params {
+		if header {
+			k = http.CanonicalHeaderKey(k)
+		}
+		declared[k] = true
+	}
+
+	var unknown []string
+	for _, k := range names {
+		if header {
+			k = http.CanonicalHeaderKey(k)
+		}
+		if !declared[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	return unknown
+}
+
+// deepKey splits a bracketed or dotted query key e.g. filter[owner][id] or filter.owner.id
+// into the parameter name and the path of the property; a trailing [] e.g. filter[tags][] is
+// dropped, so repeated keys collect their values into the array at the path
+func deepKey(k string) (string, []string) {
+	i := strings.IndexAny(k, "[.")
+	if i <= 0 {
+		return k, nil
+	}
+
+	name, rest := k[:i], k[i:]
+	var path []string
+	for rest != "" {
+		switch rest[0] {
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return k, nil
+			}
+			if end == 1 && len(rest) == 2 && len(path) > 0 {
+				return name, path
+			}
+			path = append(path, rest[1:end])
+			rest = rest[end+1:]
+		case '.':
+			end := strings.IndexAny(rest[1:], "[.")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			path = append(path, rest[1:end+1])
+			rest = rest[end+1:]
+		default:
+			return k, nil
+		}
+	}
+	return name, path
+}
+
+// setDeep sets values at path in obj, coerced to the type of the property of schema found at the same path
+func (v *Validator) setDeep(obj map[string]interface{}, schema map[string]interface{}, path []string, values []string) {
+	for i, key := range path {
+		prop := v.property(schema, key)
+		if i == len(path)-1 {
+			obj[key] = loadValueForKey(map[string]interface{}{key: prop}, key, values, "")
+			return
+		}
+
+		child, isObject := obj[key].(map[string]interface{})
+		if !isObject {
+			child = map[string]interface{}{}
+			obj[key] = child
+		}
+		obj, schema = child, prop
+	}
+}
+
+// property returns the schema of the property key of the object schema
+func (v *Validator) property(schema map[string]interface{}, key string) map[string]interface{} {
+	schema = v.resolve(schema)
+	properties, _ := schema["properties"].(map[string]interface{})
+	if prop, found := properties[key].(map[string]interface{}); found {
+		return v.resolve(prop)
+	}
+	additional, _ := schema["additionalProperties"].(map[string]interface{})
+	return v.resolve(additional)
+}
+
+// resolve follows a reference of schema to the shared definitions
+func (v *Validator) resolve(schema map[string]interface{}) map[string]interface{} {
+	ref, _ := schema["$ref"].(string)
+	if i := strings.Index(ref, "#/definitions/"); i >= 0 {
+		definition, _ := v.definitions[ref[i+len("#/definitions/"):]].(map[string]interface{})
+		return definition
+	}
+	return schema
+}
+
+// setFormValue sets the values of the declared form field k in formData; a value sent for a file
+// parameter is reported in errors instead, as it would satisfy the file and skip its constraints
+func (v *Validator) setFormValue(rt *route, formData map[string]interface{}, errors map[string]string, k string, values []string) {
+	if isFileParam(rt, k) {
+		errors[k] = v.describeType("file", "string")
+		return
+	}
+	formData[k] = rt.formValue(k, values)
+}
+
+// formValue coerces the values of the form field k to its declared type; repeated values of
+// a field that is not an array are kept as an array, which fails its type e.g. "given: array",
+// rather than the first value being validated and the others passed on unchecked
+func (rt *route) formValue(k string, values []string) interface{} {
+	return loadValueForKey(rt.params["formData"], k, values, rt.formats["formData."+k])
+}
+
+// sharedNames returns the names declared in more than one of the path, query and formData
+// locations and the top level properties of the body
+func (v *Validator) sharedNames(params map[string]map[string]interface{}, body map[string]interface{}) map[string]bool {
+	counts := map[string]int{}
+	for _, in := range []string{"path", "query", "formData"} {
+		for k := range params[in] {
+			counts[k]++
+		}
+	}
+	if body != nil {
+		properties, _ := v.resolve(body)["properties"].(map[string]interface{})
+		for k := range properties {
+			counts[k]++
+		}
+	}
+
+	shared := map[string]bool{}
+	for k, n := range counts {
+		if n > 1 {
+			shared[k] = true
+		}
+	}
+	return shared
+}
+
+// resultErrors flattens schema validation errors of rt into a field to description map
+func (v *Validator) resultErrors(rt *route, result *gojsonschema.Result) map[string]string {
+	errors := map[string]string{}
+	for _, err := range result.Errors() {
+		description := describe(v.templates, err)
+		details := err.Details()
+
+		field := details["field"].(string)
+		if val, ok := details["property"]; ok {
+			field += "." + val.(string)
+		}
+		field = strings.TrimPrefix(field, "(root).")
+		// header and cookie errors keep their location, as their names may clash with other parameters,
+		// and so do the errors of a name declared in more than one location
+		for _, in := range []string{"body.", "path.", "query.", "formData."} {
+			if strings.HasPrefix(field, in) {
+				name := strings.SplitN(strings.TrimPrefix(field, in), ".", 2)[0]
+				if !rt.shared[name] {
+					field = strings.TrimPrefix(field, in)
+				}
+				break
+			}
+		}
+		errors[field] = description
+	}
+	return errors
+}
+
+// requiresBody reports whether e declares a required body parameter
+func requiresBody(e *swagger.Endpoint) bool {
+	for _, p := range e.Parameters {
+		if p.In == "body" {
+			return p.Required
+		}
+	}
+	return false
+}
+
+// hasBody reports whether e declares a body parameter
+func hasBody(e *swagger.Endpoint) bool {
+	for _, p := range e.Parameters {
+		if p.In == "body" {
+			return true
+		}
+	}
+	return false
+}
+
+// templateColonPath converts a route template e.g. /pets/{id:[0-9]+} to a colon path e.g. /pets/:id
+func templateColonPath(template string) string {
+	return reTemplateParam.ReplaceAllString(template, ":$1")
+}
+
+// contentType returns the lower case request media type without parameters e.g. multipart/form-data
+// for "multipart/form-data; boundary=x", or an empty string when the Content-Type cannot be parsed
+func contentType(r *http.Request) string {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil && err != mime.ErrInvalidMediaParameter {
+		return ""
+	}
+	return mediaType
+}
+
+// validationError builds the response body for a failed validation
+func validationError(details map[string]string) map[string]interface{} {
+	return map[string]interface{}{
+		"message": "Validation error",
+		"details": details,
+	}
+}
+
+// schemaError builds the response body for a broken swagger schema
+func schemaError(err error) map[string]interface{} {
+	return map[string]interface{}{
+		"message": "swagger document " + err.Error(),
+	}
+}
+
+// limitedBody fails the reads of a request body past its maximum size
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	exceeded  bool
+}
+
+// errBodyTooLarge is returned by the reads of a limitedBody past its maximum size
+var errBodyTooLarge = errors.New("request body too large")
+
+// Read reads up to the remaining bytes of the body, reading any more fails with errBodyTooLarge
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= b.remaining {
+		b.remaining -= int64(n)
+		return n, err
+	}
+	b.exceeded = true
+	n = int(b.remaining)
+	b.remaining = 0
+	return n, errBodyTooLarge
+}
+
+// Exceeded reports whether the body turned out larger than its maximum size, it is false for a nil body
+func (b *limitedBody) Exceeded() bool {
+	return b != nil && b.exceeded
+}
+
+// bodyTooLarge is the result of a request whose body is larger than limit
+func bodyTooLarge(limit int64) *Result {
+	return &Result{
+		Errors: map[string]string{"body": fmt.Sprintf("Request body must be at most %d bytes", limit)},
+		Status: http.StatusRequestEntityTooLarge,
+		Code:   CodeBodyTooLarge,
+	}
+}
@@ -0,0 +1,171 @@
+package swagvalidator_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labstack/echo/v4"
+	swag "github.com/miketonks/swag"
+	"github.com/miketonks/swag/endpoint"
+	"github.com/miketonks/swag/swagger"
+	"github.com/stretchr/testify/assert"
+
+	sv "github.com/Rekfuki/swag-validator"
+)
+
+func TestValidateRequest(t *testing.T) {
+	testTable := []struct {
+		description    string
+		method         string
+		url            string
+		pathParams     map[string]string
+		expectedErr    error
+		expectedErrors map[string]string
+	}{
+		{
+			description: "Path param extracted from the request path",
+			method:      "GET",
+			url:         "/pets/abc",
+			expectedErrors: map[string]string{
+				"pet_id": "Invalid type. Expected: integer, given: string",
+			},
+		},
+		{
+			description:    "Valid path param",
+			method:         "GET",
+			url:            "/pets/10",
+			expectedErrors: map[string]string{},
+		},
+		{
+			description:    "Explicit path params take precedence",
+			method:         "GET",
+			url:            "/pets/abc",
+			pathParams:     map[string]string{"pet_id": "10"},
+			expectedErrors: map[string]string{},
+		},
+		{
+			description:    "Static route preferred over parameter",
+			method:         "GET",
+			url:            "/pets/mine",
+			expectedErrors: map[string]string{},
+		},
+		{
+			description: "Unknown route",
+			method:      "GET",
+			url:         "/owners/10",
+			expectedErr: sv.ErrRouteNotFound,
+		},
+		{
+			description: "Unknown method",
+			method:      "DELETE",
+			url:         "/pets/10",
+			expectedErr: sv.ErrRouteNotFound,
+		},
+	}
+
+	api := swag.New(swag.Endpoints(
+		endpoint.New("GET", "/pets/{pet_id}", "Get a pet",
+			endpoint.Path("pet_id", "integer", "", ""),
+		),
+		endpoint.New("GET", "/pets/mine", "Get my pets"),
+	))
+
+	v := sv.NewValidator(api)
+
+	for _, tt := range testTable {
+		t.Run(tt.description, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, nil)
+			if err != nil {
+				log.Fatalf("Error preparing request: %s", err)
+			}
+
+			result, err := v.ValidateRequest(req, tt.pathParams)
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr == nil {
+				assert.Equal(t, tt.expectedErrors, result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateRequestTie(t *testing.T) {
+	api := swag.New(swag.Endpoints(
+		endpoint.New("GET", "/a/{x}", "Leftmost static segment",
+			endpoint.Path("x", "integer", "", ""),
+		),
+		endpoint.New("GET", "/{y}/b", "Rightmost static segment",
+			endpoint.Path("y", "integer", "", ""),
+		),
+	))
+
+	// the routes are collected from a map, so the validator is built again to vary their order
+	for i := 0; i < 20; i++ {
+		req, err := http.NewRequest("GET", "/a/b", nil)
+		if err != nil {
+			log.Fatalf("Error preparing request: %s", err)
+		}
+
+		result, err := sv.NewValidator(api).ValidateRequest(req, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"x": "Invalid type. Expected: integer, given: string"}, result.Errors)
+	}
+}
+
+func BenchmarkNewValidator(b *testing.B) {
+	endpoints := []*swagger.Endpoint{}
+	for i := 0; i < 200; i++ {
+		endpoints = append(endpoints, endpoint.New("POST", fmt.Sprintf("/resource-%d/{id}", i), "Test the validator",
+			endpoint.Path("id", "integer", "", ""),
+			endpoint.Body(payload{}, "Validation body", true),
+		))
+	}
+	api := swag.New(swag.Endpoints(endpoints...))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sv.NewValidator(api)
+	}
+}
+
+// Run with -race to detect data races between concurrent requests
+func TestConcurrentValidation(t *testing.T) {
+	ginAPI := swag.New(swag.Endpoints(endpoint.New("POST", "/validate-test", "Test the validator",
+		endpoint.Handler(func(*gin.Context) {}),
+		endpoint.Body(payload{}, "Validation body", true),
+	)))
+	echoAPI := swag.New(swag.Endpoints(endpoint.New("POST", "/validate-test", "Test the validator",
+		endpoint.Handler(func(echo.Context) error { return nil }),
+		endpoint.Body(payload{}, "Validation body", true),
+	)))
+
+	handlers := []http.Handler{createEngineGin(ginAPI), createEngineEcho(echoAPI)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		for _, h := range handlers {
+			wg.Add(1)
+			go func(h http.Handler) {
+				defer wg.Done()
+
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, preparePostRequest("/validate-test", payload{Nested: &nested{}}))
+
+				var body map[string]interface{}
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Errorf("Failed to unmarshal body. Error: %s", err)
+					return
+				}
+
+				assert.Equal(t, 400, w.Code)
+				assert.Equal(t, map[string]interface{}{"nested.foo": "foo is required"}, body["details"])
+			}(h)
+		}
+	}
+	wg.Wait()
+}